@@ -1,17 +1,11 @@
 package main
 
 import (
-	"fmt"
 	"os"
 
-	"github.com/FScoward/rakutree/internal/tui"
-	tea "github.com/charmbracelet/bubbletea"
+	"github.com/FScoward/rakutree/internal/cli"
 )
 
 func main() {
-	p := tea.NewProgram(tui.NewModel(), tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
-		fmt.Printf("Error: %v\n", err)
-		os.Exit(1)
-	}
+	os.Exit(cli.Run(os.Args[1:], os.Stdout, os.Stderr))
 }
@@ -0,0 +1,666 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/FScoward/rakutree/internal/config"
+	"github.com/FScoward/rakutree/internal/files"
+	"github.com/FScoward/rakutree/internal/git"
+	"github.com/FScoward/rakutree/internal/history"
+	"github.com/FScoward/rakutree/internal/hooks"
+	"github.com/FScoward/rakutree/internal/issues"
+	"github.com/FScoward/rakutree/internal/shell"
+	"github.com/FScoward/rakutree/internal/tui"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Exit codes returned by Run
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+var (
+	// errUsage signals that the command line was malformed
+	errUsage = errors.New("usage error")
+	// errCancelled signals that the user backed out of an interactive prompt
+	errCancelled = errors.New("cancelled")
+)
+
+type command struct {
+	name    string
+	aliases []string
+	usage   string
+	summary string
+	// noConfig commands run without the config, so a broken one cannot
+	// stop them, e.g. shell-init from a shell's startup file
+	noConfig bool
+	run      func(ctx context.Context, repo *git.Repository, args []string, stdout, stderr io.Writer) error
+}
+
+func commands() []command {
+	return []command{
+		{
+			name:    "list",
+			aliases: []string{"ls"},
+			usage:   "rtr list [--format text|json|tsv|porcelain]",
+			summary: "List all worktrees",
+			run:     runList,
+		},
+		{
+			name:    "add",
+			usage:   "rtr add [--no-hooks] [--detach] <branch> [path]\n       rtr add [--no-hooks] -b <new-branch> [--base <base>] [path]",
+			summary: "Add a worktree for an existing or new branch",
+			run:     runAdd,
+		},
+		{
+			name:    "rm",
+			aliases: []string{"remove"},
+			usage:   "rtr rm [--force] [-d | -D] [--delete-remote] [--no-hooks] <path|branch>",
+			summary: "Remove a worktree",
+			run:     runRemove,
+		},
+		{
+			name:    "switch",
+			usage:   "rtr switch",
+			summary: "Pick a worktree and print its path",
+			run:     runSwitch,
+		},
+		{
+			name:     "shell-init",
+			usage:    "rtr shell-init <" + strings.Join(shell.Supported(), "|") + ">",
+			summary:  "Print shell integration so rtr can cd into worktrees",
+			noConfig: true,
+			run:      runShellInit,
+		},
+		{
+			name:    "gc",
+			usage:   "rtr gc [--dry-run] [--force] [--max-age age] [--no-hooks]",
+			summary: "Remove merged, missing and old worktrees",
+			run:     runGC,
+		},
+		{
+			name:    "trust",
+			usage:   "rtr trust [--yes]",
+			summary: "Review and allow the hooks of " + config.RepoFile,
+			run:     runTrust,
+		},
+		{
+			name:    "prune",
+			usage:   "rtr prune",
+			summary: "Prune stale worktree administrative files",
+			run:     runPrune,
+		},
+	}
+}
+
+// Run parses the command line, executes the TUI or a non-interactive
+// subcommand and returns the process exit code
+func Run(args []string, stdout, stderr io.Writer) int {
+	global := flag.NewFlagSet("rtr", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	global.Usage = func() { printUsage(stderr) }
+	dir := global.String("C", "", "run as if rtr was started in `dir`")
+	timeout := global.Duration("timeout", 0, "abort each git command after `duration` (e.g. 30s); 0 means no limit")
+	if err := global.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	repo := git.NewRepositoryWithRunner(*dir, &git.ExecRunner{Dir: *dir, Timeout: *timeout})
+
+	args = global.Args()
+	if len(args) == 0 {
+		if err := loadConfig(repo, stderr); err != nil {
+			fmt.Fprintf(stderr, "rtr: %v\n", err)
+			return exitError
+		}
+		loadHistory(repo, stderr)
+		if err := runTUI(repo, stdout, stderr); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return exitError
+		}
+		return exitOK
+	}
+
+	name := args[0]
+	if name == "help" || name == "-h" || name == "--help" {
+		printUsage(stdout)
+		return exitOK
+	}
+
+	cmd, ok := findCommand(name)
+	if !ok {
+		fmt.Fprintf(stderr, "rtr: unknown command %q\n\n", name)
+		printUsage(stderr)
+		return exitUsage
+	}
+	if !cmd.noConfig {
+		if err := loadConfig(repo, stderr); err != nil {
+			fmt.Fprintf(stderr, "rtr: %v\n", err)
+			return exitError
+		}
+		loadHistory(repo, stderr)
+	}
+
+	// Ctrl-C kills the running git process instead of leaving it orphaned
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := cmd.run(ctx, repo, args[1:], stdout, stderr); err != nil {
+		if errors.Is(err, errUsage) {
+			fmt.Fprintf(stderr, "usage: %s\n", cmd.usage)
+			return exitUsage
+		}
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		if errors.Is(err, errCancelled) {
+			return exitError
+		}
+		fmt.Fprintf(stderr, "rtr %s: %v\n", cmd.name, err)
+		return exitError
+	}
+	return exitOK
+}
+
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands() {
+		if cmd.name == name {
+			return cmd, true
+		}
+		for _, alias := range cmd.aliases {
+			if alias == name {
+				return cmd, true
+			}
+		}
+	}
+	return command{}, false
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: rtr [-C dir] [--timeout duration] [command] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run without a command to start the interactive UI.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands() {
+		fmt.Fprintf(w, "  %-11s %s\n", cmd.name, cmd.summary)
+	}
+}
+
+// loadConfig applies the user and repository config to repo, including
+// the issue tracker branch names are offered from. Outside a git
+// repository only the user config applies. The hooks of the repository
+// config are left out until the user trusts them with 'rtr trust'. An
+// incomplete issue tracker only costs its branch names.
+func loadConfig(repo *git.Repository, stderr io.Writer) error {
+	dir, commonDir := "", ""
+	if root, err := repo.Root(context.Background()); err == nil {
+		dir = root.TopLevel
+		if dir == "" {
+			dir = root.Main
+		}
+		commonDir = root.CommonDir
+	}
+
+	cfg, err := config.Load(dir)
+	if err != nil {
+		return err
+	}
+	if !cfg.RepoHooks.IsEmpty() {
+		// Hooks are shell commands, so a cloned repository must not get to
+		// run them unseen. An unreadable trust file trusts nothing; 'rtr
+		// trust' reports the error.
+		if trusted, _ := hooks.IsTrusted(commonDir, cfg.RepoHooks); !trusted {
+			cfg = cfg.WithoutRepoHooks()
+		}
+	}
+	repo.SetConfig(cfg)
+
+	if cfg.IssueTracker.Type != "" {
+		tracker, err := issues.New(cfg.IssueTracker)
+		if err != nil {
+			fmt.Fprintf(stderr, "rtr: %v; branch names are not offered from issues\n", err)
+			return nil
+		}
+		repo.AddBranchNameProvider(tracker)
+	}
+	return nil
+}
+
+// loadHistory gives repo the remembered choices of earlier runs to rank
+// its suggestions by. A broken history file only costs the ranking.
+func loadHistory(repo *git.Repository, stderr io.Writer) {
+	root, err := repo.Root(context.Background())
+	if err != nil {
+		return
+	}
+	h, err := history.Load(root.CommonDir)
+	if err != nil {
+		fmt.Fprintf(stderr, "rtr: %v; suggestions are not ranked by history\n", err)
+		return
+	}
+	repo.SetHistory(h)
+}
+
+// runTUI starts the interactive UI on repo
+func runTUI(repo *git.Repository, stdout, stderr io.Writer) error {
+	p := tea.NewProgram(tui.NewModel(repo), tea.WithAltScreen())
+	final, err := p.Run()
+	if err != nil {
+		return err
+	}
+
+	m, ok := final.(tui.Model)
+	if !ok {
+		return nil
+	}
+	if m.Jumped() && !shell.Wrapped() {
+		// Nothing can change the shell's directory, so say where to go
+		fmt.Fprintln(stdout, m.JumpTarget())
+		fmt.Fprintln(stderr, "rtr: to change into worktrees, add the shell integration (see 'rtr shell-init')")
+		return nil
+	}
+	// Let the shell wrapper jump into a freshly created worktree
+	return shell.RecordTarget(m.JumpTarget())
+}
+
+// newFlagSet creates a flag set that reports errors instead of exiting
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("rtr "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	return fs
+}
+
+// parseFlags parses args and maps flag errors onto errUsage
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	return nil
+}
+
+func runList(ctx context.Context, repo *git.Repository, args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("list", stderr)
+	format := fs.String("format", formatText, "output format: "+strings.Join(listFormats, ", "))
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 || !isListFormat(*format) {
+		return errUsage
+	}
+
+	worktrees, err := repo.ListWorktrees(ctx)
+	if err != nil {
+		return err
+	}
+
+	return writeWorktrees(stdout, worktrees, *format)
+}
+
+func isListFormat(format string) bool {
+	for _, f := range listFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+func runAdd(ctx context.Context, repo *git.Repository, args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("add", stderr)
+	newBranch := fs.String("b", "", "create a new branch with the given name")
+	defaultBase := repo.Config().DefaultBase
+	if defaultBase == "" {
+		defaultBase = "HEAD"
+	}
+	base := fs.String("base", defaultBase, "base branch for the new branch (with -b)")
+	noHooks := fs.Bool("no-hooks", false, "do not run the post-create hooks")
+	detach := fs.Bool("detach", false, "check out the branch's commit with a detached HEAD (e.g. when the branch is checked out elsewhere)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	var branch, path string
+	if *newBranch != "" {
+		if fs.NArg() > 1 || *detach {
+			return errUsage
+		}
+		branch = *newBranch
+		path = fs.Arg(0)
+
+		if err := checkNewBranchName(ctx, repo, branch); err != nil {
+			return err
+		}
+	} else {
+		if fs.NArg() < 1 || fs.NArg() > 2 {
+			return errUsage
+		}
+		branch = fs.Arg(0)
+		path = fs.Arg(1)
+
+		if !*detach {
+			if err := checkNotCheckedOut(ctx, repo, branch); err != nil {
+				return err
+			}
+		}
+	}
+
+	suggestFor := branch
+	if *detach {
+		// Keep the suggestion apart from the worktree that has the branch
+		suggestFor = branch + "-detached"
+	}
+	var template string
+	if path == "" {
+		suggested, err := defaultPath(ctx, repo, suggestFor)
+		if err != nil {
+			return err
+		}
+		path, template = suggested.Path, suggested.Template
+	} else {
+		template = repo.PathTemplate(path, suggestFor)
+	}
+
+	// Resolve the file patterns first so a bad one fails before anything is created
+	plan, err := files.PlanForRepo(ctx, repo)
+	if err != nil {
+		return err
+	}
+
+	hookBase := ""
+	if *newBranch != "" {
+		if err := repo.AddWorktreeWithNewBranch(ctx, path, branch, *base); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "Created branch '%s' and worktree at %s\n", branch, path)
+		hookBase = *base
+	} else if *detach {
+		if err := repo.AddWorktreeDetached(ctx, path, branch); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "Added detached worktree at %s (at '%s')\n", path, branch)
+		hookBase, branch = branch, ""
+	} else {
+		if err := repo.AddWorktree(ctx, path, branch); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "Added worktree at %s\n", path)
+	}
+	if err := recordTarget(repo, path); err != nil {
+		return err
+	}
+	// The default base is no choice worth remembering
+	rememberBase := ""
+	if *base != "HEAD" {
+		rememberBase = *base
+	}
+	if err := repo.RememberWorktree(template, branch, *newBranch != "", rememberBase); err != nil {
+		fmt.Fprintf(stderr, "rtr: %v\n", err)
+	}
+
+	absPath, err := repo.AbsPath(path)
+	if err != nil {
+		return err
+	}
+	if err := plan.Apply(absPath, stdout); err != nil {
+		return fmt.Errorf("worktree was created at %s, but %w", path, err)
+	}
+
+	if !*noHooks {
+		// Hook output goes to stderr so stdout stays parseable
+		hooks.WarnSkipped(stderr, repo.Config().SkippedHooks.PostCreate)
+		if err := hooks.RunForWorktree(ctx, repo, repo.Config().Hooks.PostCreate, path, branch, hookBase, stderr); err != nil {
+			return fmt.Errorf("worktree was created at %s, but %w", path, err)
+		}
+	}
+	return nil
+}
+
+// checkNotCheckedOut fails with a helpful message if a worktree already
+// has branch checked out, which git would refuse
+func checkNotCheckedOut(ctx context.Context, repo *git.Repository, branch string) error {
+	worktrees, err := repo.ListWorktrees(ctx)
+	if err != nil {
+		return err
+	}
+	for _, wt := range worktrees {
+		if wt.Branch == branch {
+			return fmt.Errorf("branch '%s' is already checked out at %s; cd there, or use --detach to add a worktree at its commit", branch, wt.Path)
+		}
+	}
+	return nil
+}
+
+// checkNewBranchName fails before anything is created if branch is not a
+// valid name or clashes with an existing branch, suggesting a valid one
+func checkNewBranchName(ctx context.Context, repo *git.Repository, branch string) error {
+	branches, err := repo.ListBranches(ctx)
+	if err != nil {
+		return err
+	}
+	if err := git.CheckNewBranchName(branch, branches); err != nil {
+		slug := git.SanitizeBranchName(branch)
+		if slug != branch && git.CheckNewBranchName(slug, branches) == nil {
+			return fmt.Errorf("%w; try '%s'", err, slug)
+		}
+		return err
+	}
+	return nil
+}
+
+// defaultPath returns the top path suggestion for branch that does not
+// collide with an existing file or worktree
+func defaultPath(ctx context.Context, repo *git.Repository, branch string) (git.PathSuggestion, error) {
+	suggestions, err := repo.SuggestPaths(ctx, branch)
+	if err != nil {
+		return git.PathSuggestion{}, err
+	}
+	for _, sug := range suggestions {
+		if !sug.IsCustom && sug.Path != "" && sug.Warning == "" {
+			return sug, nil
+		}
+	}
+	return git.PathSuggestion{}, fmt.Errorf("could not suggest a free path for branch '%s'; pass one explicitly", branch)
+}
+
+func runRemove(ctx context.Context, repo *git.Repository, args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("rm", stderr)
+	var force bool
+	fs.BoolVar(&force, "force", false, "remove even with uncommitted changes, untracked files or a lock")
+	fs.BoolVar(&force, "f", false, "shorthand for --force")
+	var deleteBranch, forceDeleteBranch bool
+	fs.BoolVar(&deleteBranch, "delete-branch", false, "also delete the branch if it is fully merged")
+	fs.BoolVar(&deleteBranch, "d", false, "shorthand for --delete-branch")
+	fs.BoolVar(&forceDeleteBranch, "force-delete-branch", false, "also delete the branch even if it is not merged")
+	fs.BoolVar(&forceDeleteBranch, "D", false, "shorthand for --force-delete-branch")
+	deleteRemote := fs.Bool("delete-remote", false, "also delete the remote branch (requires -d or -D)")
+	noHooks := fs.Bool("no-hooks", false, "do not run the pre-remove hooks")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || (*deleteRemote && !deleteBranch && !forceDeleteBranch) {
+		return errUsage
+	}
+
+	deletion := git.KeepBranch
+	switch {
+	case forceDeleteBranch:
+		deletion = git.ForceDeleteBranch
+	case deleteBranch:
+		deletion = git.DeleteMergedBranch
+	}
+
+	worktrees, err := repo.ListWorktrees(ctx)
+	if err != nil {
+		return err
+	}
+
+	wt, err := findWorktree(repo, worktrees, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	preflight, err := repo.PreflightRemoval(ctx, wt, deletion != git.KeepBranch)
+	if err != nil {
+		return err
+	}
+	for _, risk := range preflight.Risks() {
+		fmt.Fprintf(stderr, "warning: %s\n", risk)
+	}
+	if preflight.NeedsForce() && !force {
+		return fmt.Errorf("%s has unsaved work; re-run with --force to discard it", wt.Path)
+	}
+	if deletion != git.KeepBranch {
+		if wt.Branch == "" {
+			return fmt.Errorf("%s has a detached HEAD; there is no branch to delete", wt.Path)
+		}
+		// Check before removing anything so a refusal leaves everything intact
+		if deletion == git.DeleteMergedBranch && !preflight.BranchMerged {
+			return fmt.Errorf("branch '%s' is not fully merged; use -D to delete it anyway", wt.Branch)
+		}
+		if *deleteRemote && preflight.Remote == "" {
+			return fmt.Errorf("branch '%s' has no remote upstream to delete", wt.Branch)
+		}
+	}
+
+	// A missing directory has nothing left to run the hooks in
+	if !*noHooks && !wt.Prunable {
+		hooks.WarnSkipped(stderr, repo.Config().SkippedHooks.PreRemove)
+		if err := hooks.RunForWorktree(ctx, repo, repo.Config().Hooks.PreRemove, wt.Path, wt.Branch, "", stderr); err != nil {
+			return fmt.Errorf("%w; %s was not removed", err, wt.Path)
+		}
+	}
+
+	opts := git.RemoveOptions{
+		Force:        force,
+		Branch:       wt.Branch,
+		DeleteBranch: deletion,
+		DeleteRemote: *deleteRemote,
+	}
+	if err := repo.RemoveWorktree(ctx, wt.Path, opts); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Removed worktree at %s\n", wt.Path)
+	if deletion != git.KeepBranch {
+		fmt.Fprintf(stdout, "Deleted branch '%s'\n", wt.Branch)
+	}
+	if *deleteRemote {
+		fmt.Fprintf(stdout, "Deleted remote branch '%s/%s'\n", preflight.Remote, preflight.RemoteBranch)
+	}
+	return nil
+}
+
+// findWorktree resolves target as a worktree path or branch name.
+// The main worktree (first entry) is never returned.
+func findWorktree(repo *git.Repository, worktrees []git.Worktree, target string) (git.Worktree, error) {
+	if len(worktrees) == 0 {
+		return git.Worktree{}, fmt.Errorf("no worktrees found")
+	}
+
+	absTarget, err := repo.AbsPath(target)
+	if err != nil {
+		absTarget = target
+	}
+
+	for i, wt := range worktrees {
+		if wt.Path == absTarget || wt.Path == target || wt.Branch == target {
+			if i == 0 {
+				return git.Worktree{}, fmt.Errorf("refusing to remove the main worktree at %s", wt.Path)
+			}
+			return wt, nil
+		}
+	}
+
+	if _, err := os.Stat(absTarget); err == nil {
+		return git.Worktree{}, fmt.Errorf("%s is not a worktree", target)
+	}
+	return git.Worktree{}, fmt.Errorf("no worktree matches '%s'", target)
+}
+
+func runSwitch(ctx context.Context, repo *git.Repository, args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("switch", stderr)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return errUsage
+	}
+
+	// Render the picker on stderr and read keys from the terminal so that
+	// stdout only carries the chosen path, e.g. cd "$(rtr switch)"
+	p := tea.NewProgram(tui.NewSwitchModel(repo),
+		tea.WithAltScreen(),
+		tea.WithOutput(stderr),
+		tea.WithInputTTY(),
+	)
+	final, err := p.Run()
+	if err != nil {
+		return err
+	}
+
+	m, ok := final.(tui.Model)
+	if !ok || m.JumpTarget() == "" {
+		return errCancelled
+	}
+
+	fmt.Fprintln(stdout, m.JumpTarget())
+	return shell.RecordTarget(m.JumpTarget())
+}
+
+func runShellInit(ctx context.Context, repo *git.Repository, args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("shell-init", stderr)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errUsage
+	}
+
+	script, err := shell.Init(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(stdout, script)
+	return err
+}
+
+// recordTarget resolves path against the repository and hands it to the
+// shell wrapper
+func recordTarget(repo *git.Repository, path string) error {
+	absPath, err := repo.AbsPath(path)
+	if err != nil {
+		return err
+	}
+	return shell.RecordTarget(absPath)
+}
+
+func runPrune(ctx context.Context, repo *git.Repository, args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("prune", stderr)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return errUsage
+	}
+
+	if err := repo.PruneWorktrees(ctx); err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, "Pruned stale worktree information")
+	return nil
+}
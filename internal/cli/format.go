@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/FScoward/rakutree/internal/git"
+)
+
+// Output formats accepted by --format
+const (
+	formatText      = "text"
+	formatJSON      = "json"
+	formatTSV       = "tsv"
+	formatPorcelain = "porcelain"
+)
+
+var listFormats = []string{formatText, formatJSON, formatTSV, formatPorcelain}
+
+// writeWorktrees serializes worktrees to w in the requested format
+func writeWorktrees(w io.Writer, worktrees []git.Worktree, format string) error {
+	switch format {
+	case formatText:
+		return writeText(w, worktrees)
+	case formatJSON:
+		return writeJSON(w, worktrees)
+	case formatTSV:
+		return writeTSV(w, worktrees)
+	case formatPorcelain:
+		return writePorcelain(w, worktrees)
+	}
+	return fmt.Errorf("unknown format %q (expected one of: %s)", format, strings.Join(listFormats, ", "))
+}
+
+// writeText prints an aligned table for humans
+func writeText(w io.Writer, worktrees []git.Worktree) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, wt := range worktrees {
+		branch := wt.Branch
+		if branch == "" {
+			branch = "(detached)"
+		}
+		var notes string
+		if labels := wt.Annotations(); len(labels) > 0 {
+			notes = "[" + strings.Join(labels, ", ") + "]"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%.7s\t%s\n", wt.Path, branch, wt.Commit, notes)
+	}
+	return tw.Flush()
+}
+
+// writeJSON prints the worktrees as a JSON array
+func writeJSON(w io.Writer, worktrees []git.Worktree) error {
+	if worktrees == nil {
+		worktrees = []git.Worktree{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(worktrees)
+}
+
+// writeTSV prints one worktree per line: path, branch, commit and a
+// comma-separated list of state flags ("-" when there are none)
+func writeTSV(w io.Writer, worktrees []git.Worktree) error {
+	for _, wt := range worktrees {
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", wt.Path, wt.Branch, wt.Commit, stateFlags(wt)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stateFlags returns the worktree's special states as a compact token
+func stateFlags(wt git.Worktree) string {
+	var flags []string
+	if wt.IsBare {
+		flags = append(flags, "bare")
+	}
+	if wt.IsDetached {
+		flags = append(flags, "detached")
+	}
+	if wt.Locked {
+		flags = append(flags, "locked")
+	}
+	if wt.Prunable {
+		flags = append(flags, "prunable")
+	}
+	if len(flags) == 0 {
+		return "-"
+	}
+	return strings.Join(flags, ",")
+}
+
+// writePorcelain prints the worktrees in the same layout as
+// 'git worktree list --porcelain'
+func writePorcelain(w io.Writer, worktrees []git.Worktree) error {
+	var b strings.Builder
+	for _, wt := range worktrees {
+		fmt.Fprintf(&b, "worktree %s\n", wt.Path)
+		if wt.IsBare {
+			b.WriteString("bare\n")
+		} else {
+			if wt.Commit != "" {
+				fmt.Fprintf(&b, "HEAD %s\n", wt.Commit)
+			}
+			if wt.Branch != "" {
+				fmt.Fprintf(&b, "branch refs/heads/%s\n", wt.Branch)
+			} else {
+				b.WriteString("detached\n")
+			}
+		}
+		if wt.Locked {
+			writeAttr(&b, "locked", wt.LockReason)
+		}
+		if wt.Prunable {
+			writeAttr(&b, "prunable", wt.PrunableReason)
+		}
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeAttr writes a porcelain attribute line with an optional value
+func writeAttr(b *strings.Builder, key, value string) {
+	if value == "" {
+		fmt.Fprintf(b, "%s\n", key)
+		return
+	}
+	fmt.Fprintf(b, "%s %s\n", key, value)
+}
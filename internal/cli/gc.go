@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/FScoward/rakutree/internal/config"
+	"github.com/FScoward/rakutree/internal/git"
+	"github.com/FScoward/rakutree/internal/hooks"
+)
+
+func runGC(ctx context.Context, repo *git.Repository, args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("gc", stderr)
+	dryRun := fs.Bool("dry-run", false, "only report what would be removed")
+	force := fs.Bool("force", false, "also remove stale worktrees with uncommitted changes or untracked files")
+	maxAge := fs.String("max-age", repo.Config().GC.MaxAge, "also remove worktrees whose last commit is older than `age` (e.g. 30d, 2w, 12h; 0 to disable)")
+	noHooks := fs.Bool("no-hooks", false, "do not run the pre-remove hooks")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return errUsage
+	}
+
+	age, err := config.ParseAge(*maxAge)
+	if err != nil {
+		return err
+	}
+
+	stale, mergedInto, err := repo.FindStaleWorktrees(ctx, git.StaleOptions{MaxAge: age})
+	if err != nil {
+		return err
+	}
+	if mergedInto == "" {
+		fmt.Fprintln(stderr, "warning: no default branch found, so merged worktrees were not looked for; set default_base in the config")
+	}
+	if len(stale) == 0 {
+		fmt.Fprintln(stdout, "No stale worktrees found")
+	} else if !*dryRun && !*noHooks {
+		hooks.WarnSkipped(stderr, repo.Config().SkippedHooks.PreRemove)
+	}
+
+	failed := 0
+	for _, s := range stale {
+		path := s.Worktree.Path
+		reasons := strings.Join(s.Reasons, ", ")
+
+		preflight, err := repo.PreflightRemoval(ctx, s.Worktree, false)
+		if err != nil {
+			fmt.Fprintf(stderr, "error: %s: %v\n", path, err)
+			failed++
+			continue
+		}
+		if preflight.NeedsForce() && !*force {
+			fmt.Fprintf(stdout, "skip %s (%s): %s\n", path, reasons, strings.Join(preflight.Risks(), "; "))
+			continue
+		}
+
+		if *dryRun {
+			fmt.Fprintf(stdout, "would remove %s (%s)\n", path, reasons)
+			continue
+		}
+		if !*noHooks && !s.Worktree.Prunable {
+			if err := hooks.RunForWorktree(ctx, repo, repo.Config().Hooks.PreRemove, path, s.Worktree.Branch, "", stderr); err != nil {
+				fmt.Fprintf(stderr, "error: %s: %v\n", path, err)
+				failed++
+				continue
+			}
+		}
+		if err := repo.RemoveWorktree(ctx, path, git.RemoveOptions{Force: *force}); err != nil {
+			fmt.Fprintf(stderr, "error: %s: %v\n", path, err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(stdout, "removed %s (%s)\n", path, reasons)
+	}
+
+	if !*dryRun {
+		if err := repo.PruneWorktrees(ctx); err != nil {
+			return err
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to remove %d of %d stale worktrees", failed, len(stale))
+	}
+	return nil
+}
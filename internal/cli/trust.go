@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/FScoward/rakutree/internal/config"
+	"github.com/FScoward/rakutree/internal/git"
+	"github.com/FScoward/rakutree/internal/hooks"
+)
+
+// runTrust shows the hooks of the repository config and, once the user
+// confirms, lets them run from now on. Changing them requires trusting
+// them again.
+func runTrust(ctx context.Context, repo *git.Repository, args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("trust", stderr)
+	yes := fs.Bool("yes", false, "trust the hooks without asking")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return errUsage
+	}
+
+	root, err := repo.Root(ctx)
+	if err != nil {
+		return err
+	}
+	h := repo.Config().RepoHooks
+	if h.IsEmpty() {
+		fmt.Fprintf(stdout, "%s has no hooks\n", config.RepoFile)
+		return nil
+	}
+
+	printHooks(stdout, "post_create", h.PostCreate)
+	printHooks(stdout, "pre_remove", h.PreRemove)
+	if trusted, _ := hooks.IsTrusted(root.CommonDir, h); trusted {
+		fmt.Fprintf(stdout, "The hooks of %s are already trusted\n", config.RepoFile)
+		return nil
+	}
+	if !*yes && !confirm(os.Stdin, stdout, "Run these commands when adding and removing worktrees?") {
+		fmt.Fprintln(stdout, "Not trusted")
+		return errCancelled
+	}
+	if err := hooks.Trust(root.CommonDir, h); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Trusted the hooks of %s\n", config.RepoFile)
+	return nil
+}
+
+func printHooks(w io.Writer, name string, commands []string) {
+	if len(commands) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "%s:\n", name)
+	for _, command := range commands {
+		fmt.Fprintf(w, "  $ %s\n", command)
+	}
+}
+
+// confirm asks question on out and reports whether the answer read from in
+// is yes. Anything else, including no answer at all, is no.
+func confirm(in io.Reader, out io.Writer, question string) bool {
+	fmt.Fprintf(out, "%s [y/N] ", question)
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil {
+		// The input ended without a newline of the user's own
+		fmt.Fprintln(out)
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,343 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// RepoFile is the name of the repository-level config file, looked up in
+// the root of the worktree rakutree runs in
+const RepoFile = ".rakutree.toml"
+
+// Config is the rakutree configuration. Relative paths in it have already
+// been resolved against the directory of the file they were read from.
+type Config struct {
+	// WorktreeRoot is the directory new worktrees are created under
+	WorktreeRoot string `toml:"worktree_root"`
+	// PathTemplates are extra path suggestions for new worktrees, with
+	// placeholders such as {branch|slug} (see git.TemplateVars)
+	PathTemplates []string `toml:"path_templates"`
+	// DefaultBase is the branch new branches are created from
+	DefaultBase string `toml:"default_base"`
+	// FetchOnAdd fetches all remotes before the branches to add a worktree
+	// for are listed; nil means not set
+	FetchOnAdd *bool `toml:"fetch_on_add"`
+	// Prefixes are the branch prefixes the team uses
+	Prefixes []Prefix `toml:"prefixes"`
+	Hooks    Hooks    `toml:"hooks"`
+	Files    Files    `toml:"files"`
+	GC       GC       `toml:"gc"`
+	// IssueTracker offers branch names for the user's issues
+	IssueTracker IssueTracker `toml:"issue_tracker"`
+
+	// RepoHooks are the hooks of the repository config, which anyone
+	// with push access can change. See WithoutRepoHooks.
+	RepoHooks Hooks `toml:"-"`
+	// SkippedHooks are the repository hooks WithoutRepoHooks left out
+	SkippedHooks Hooks `toml:"-"`
+}
+
+// Prefix is a branch name prefix such as "feature/"
+type Prefix struct {
+	Name        string `toml:"name"`
+	Description string `toml:"description"`
+}
+
+// Hooks are shell commands run around worktree operations
+type Hooks struct {
+	// PostCreate runs inside a worktree after it has been created
+	PostCreate []string `toml:"post_create"`
+	// PreRemove runs inside a worktree before it is removed
+	PreRemove []string `toml:"pre_remove"`
+}
+
+// Files are glob patterns, relative to the main worktree, of untracked
+// files to bring into every new worktree
+type Files struct {
+	// Copy lists the files to copy
+	Copy []string `toml:"copy"`
+	// Symlink lists the files to link to instead, so changes are shared
+	Symlink []string `toml:"symlink"`
+}
+
+// GC controls which worktrees are cleaned up
+type GC struct {
+	// MaxAge also cleans up worktrees whose last commit is older than
+	// this, e.g. "30d"; empty disables the check
+	MaxAge string `toml:"max_age"`
+}
+
+// IssueTracker is where issues to name branches after are looked up
+type IssueTracker struct {
+	// Type is the API flavor: "github", "gitlab" or "jira"
+	Type string `toml:"type"`
+	// URL is the base URL of the API; GitHub and GitLab default to their
+	// public instances. It and the credentials are only read from the
+	// user config.
+	URL string `toml:"url"`
+	// Project is "owner/repo" on GitHub, the project path on GitLab and
+	// the project key on Jira
+	Project string `toml:"project"`
+	// Query narrows the issues: JQL on Jira, URL query parameters such as
+	// "assignee=octocat" on GitHub and GitLab
+	Query string `toml:"query"`
+	// User is the account email for Jira Cloud, which uses basic auth
+	User string `toml:"user"`
+	// Token is the API token; TokenEnv names an environment variable
+	// holding it instead, keeping it out of shared files
+	Token    string `toml:"token"`
+	TokenEnv string `toml:"token_env"`
+	// Prefix starts the branch names, e.g. "feature/"; the top suggested
+	// prefix is used if empty
+	Prefix string `toml:"prefix"`
+}
+
+// UserFile returns the path of the user-level config file:
+// $XDG_CONFIG_HOME/rakutree/config.toml, or ~/.config/rakutree/config.toml
+func UserFile() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "rakutree", "config.toml"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "rakutree", "config.toml"), nil
+}
+
+// Load reads the user-level config and the .rakutree.toml in repoRoot
+// and merges them, the repository config taking precedence. Missing
+// files are not an error. An empty repoRoot skips the repository config.
+func Load(repoRoot string) (Config, error) {
+	var cfg Config
+
+	if path, err := UserFile(); err == nil {
+		user, err := loadFile(path)
+		if err != nil {
+			return Config{}, err
+		}
+		cfg = user
+	}
+
+	if repoRoot != "" {
+		path := filepath.Join(repoRoot, RepoFile)
+		repo, err := loadFile(path)
+		if err != nil {
+			return Config{}, err
+		}
+		if err := checkRepoIssueTracker(path, repo.IssueTracker); err != nil {
+			return Config{}, err
+		}
+		cfg = merge(cfg, repo)
+		cfg.RepoHooks = repo.Hooks
+	}
+
+	return cfg, nil
+}
+
+// loadFile decodes the config file at path, returning an empty config if
+// it does not exist
+func loadFile(path string) (Config, error) {
+	var cfg Config
+	meta, err := toml.DecodeFile(path, &cfg)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return Config{}, nil
+		}
+		return Config{}, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		return Config{}, fmt.Errorf("%s: unknown setting '%s'", path, undecoded[0])
+	}
+	for _, p := range cfg.Prefixes {
+		if p.Name == "" {
+			return Config{}, fmt.Errorf("%s: branch prefix without a name", path)
+		}
+	}
+
+	if cfg.GC.MaxAge != "" {
+		if _, err := ParseAge(cfg.GC.MaxAge); err != nil {
+			return Config{}, fmt.Errorf("%s: gc.max_age: %w", path, err)
+		}
+	}
+
+	if cfg.WorktreeRoot != "" {
+		cfg.WorktreeRoot = resolvePath(cfg.WorktreeRoot, filepath.Dir(path))
+	}
+	for i, template := range cfg.PathTemplates {
+		cfg.PathTemplates[i] = resolvePath(template, filepath.Dir(path))
+	}
+	return cfg, nil
+}
+
+// checkRepoIssueTracker rejects the tracker settings a repository config
+// must not set: the URL and credentials belong to the user config
+func checkRepoIssueTracker(path string, t IssueTracker) error {
+	settings := []struct{ name, value string }{
+		{"url", t.URL},
+		{"user", t.User},
+		{"token", t.Token},
+		{"token_env", t.TokenEnv},
+	}
+	for _, setting := range settings {
+		if setting.value != "" {
+			return fmt.Errorf("%s: issue_tracker.%s can only be set in the user config", path, setting.name)
+		}
+	}
+	return nil
+}
+
+// resolvePath expands a leading ~ and makes path absolute relative to dir
+func resolvePath(path, dir string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[1:])
+		}
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	return filepath.Clean(path)
+}
+
+// merge layers override on top of base. Settings override sets replace
+// those of base; prefixes are combined, override's first, with a prefix
+// redefined in override taking its description from there. Path
+// templates of both are suggested, hooks of both run, override's first,
+// and the file patterns of both apply. Issue tracker settings are merged
+// one by one.
+func merge(base, override Config) Config {
+	merged := base
+	if override.WorktreeRoot != "" {
+		merged.WorktreeRoot = override.WorktreeRoot
+	}
+	if override.DefaultBase != "" {
+		merged.DefaultBase = override.DefaultBase
+	}
+	if override.FetchOnAdd != nil {
+		merged.FetchOnAdd = override.FetchOnAdd
+	}
+	if override.GC.MaxAge != "" {
+		merged.GC.MaxAge = override.GC.MaxAge
+	}
+
+	merged.PathTemplates = append(append([]string(nil), override.PathTemplates...), base.PathTemplates...)
+
+	merged.Prefixes = append([]Prefix(nil), override.Prefixes...)
+	for _, p := range base.Prefixes {
+		if _, ok := merged.Prefix(p.Name); !ok {
+			merged.Prefixes = append(merged.Prefixes, p)
+		}
+	}
+
+	merged.IssueTracker = mergeIssueTracker(base.IssueTracker, override.IssueTracker)
+
+	merged.Hooks.PostCreate = append(append([]string(nil), override.Hooks.PostCreate...), base.Hooks.PostCreate...)
+	merged.Hooks.PreRemove = append(append([]string(nil), override.Hooks.PreRemove...), base.Hooks.PreRemove...)
+	merged.Files.Copy = append(append([]string(nil), override.Files.Copy...), base.Files.Copy...)
+	merged.Files.Symlink = append(append([]string(nil), override.Files.Symlink...), base.Files.Symlink...)
+	return merged
+}
+
+// mergeIssueTracker layers the tracker settings of override on top of
+// base one by one, so the repository can name the tracker while the user
+// config holds the token. Where issues are fetched from and the
+// credentials sent there only come from base, and only when override
+// names the same type of tracker, so a cloned repository cannot send the
+// user's token elsewhere.
+func mergeIssueTracker(base, override IssueTracker) IssueTracker {
+	pick := func(override, base string) string {
+		if override != "" {
+			return override
+		}
+		return base
+	}
+	merged := IssueTracker{
+		Type:    pick(override.Type, base.Type),
+		Project: pick(override.Project, base.Project),
+		Query:   pick(override.Query, base.Query),
+		Prefix:  pick(override.Prefix, base.Prefix),
+	}
+	if merged.Type == base.Type {
+		merged.URL = base.URL
+		merged.User = base.User
+		merged.Token = base.Token
+		merged.TokenEnv = base.TokenEnv
+	}
+	return merged
+}
+
+// IsEmpty reports whether there are no hook commands
+func (h Hooks) IsEmpty() bool {
+	return len(h.PostCreate) == 0 && len(h.PreRemove) == 0
+}
+
+// WithoutRepoHooks returns c with the repository hooks left out of Hooks,
+// for when the user has not trusted them. They are kept as SkippedHooks
+// so what is not run can be reported.
+func (c Config) WithoutRepoHooks() Config {
+	// merge puts the repository hooks first
+	c.Hooks.PostCreate = c.Hooks.PostCreate[len(c.RepoHooks.PostCreate):]
+	c.Hooks.PreRemove = c.Hooks.PreRemove[len(c.RepoHooks.PreRemove):]
+	c.SkippedHooks = c.RepoHooks
+	return c
+}
+
+// ShouldFetchOnAdd reports whether remotes are fetched before adding a worktree
+func (c Config) ShouldFetchOnAdd() bool {
+	return c.FetchOnAdd != nil && *c.FetchOnAdd
+}
+
+// GCMaxAge returns the age after which worktrees are cleaned up; zero if
+// not set
+func (c Config) GCMaxAge() time.Duration {
+	// Checked when the config was loaded
+	age, _ := ParseAge(c.GC.MaxAge)
+	return age
+}
+
+// ParseAge parses a duration that may also use d (days) and w (weeks)
+// units. An empty string is zero.
+func ParseAge(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	units := map[string]time.Duration{
+		"d": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
+	}
+	for suffix, unit := range units {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			count, err := strconv.Atoi(n)
+			if err != nil || count < 0 {
+				return 0, fmt.Errorf("invalid age %q", s)
+			}
+			return time.Duration(count) * unit, nil
+		}
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q (use e.g. 30d, 2w or 12h)", s)
+	}
+	return d, nil
+}
+
+// Prefix returns the configured prefix called name
+func (c Config) Prefix(name string) (Prefix, bool) {
+	for _, p := range c.Prefixes {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Prefix{}, false
+}
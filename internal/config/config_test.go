@@ -0,0 +1,83 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeConfigs writes the user config and the repository config and
+// returns the repository root
+func writeConfigs(t *testing.T, user, repo string) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	if err := os.MkdirAll(filepath.Join(home, "rakutree"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, "rakutree", "config.toml"), []byte(user), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, RepoFile), []byte(repo), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestLoadIssueTracker(t *testing.T) {
+	tests := []struct {
+		name string
+		user string
+		repo string
+		want IssueTracker
+	}{
+		{
+			name: "repository names the tracker, user holds the token",
+			user: "[issue_tracker]\ntype = \"gitlab\"\nurl = \"https://gitlab.example.com\"\ntoken_env = \"GITLAB_TOKEN\"\n",
+			repo: "[issue_tracker]\ntype = \"gitlab\"\nproject = \"grp/app\"\nprefix = \"fix/\"\n",
+			want: IssueTracker{Type: "gitlab", URL: "https://gitlab.example.com", Project: "grp/app", TokenEnv: "GITLAB_TOKEN", Prefix: "fix/"},
+		},
+		{
+			name: "repository leaves the type to the user",
+			user: "[issue_tracker]\ntype = \"github\"\ntoken_env = \"GITHUB_TOKEN\"\n",
+			repo: "[issue_tracker]\nproject = \"acme/app\"\n",
+			want: IssueTracker{Type: "github", Project: "acme/app", TokenEnv: "GITHUB_TOKEN"},
+		},
+		{
+			name: "another type of tracker gets no credentials",
+			user: "[issue_tracker]\ntype = \"github\"\nurl = \"https://github.example.com/api/v3\"\ntoken_env = \"GITHUB_TOKEN\"\n",
+			repo: "[issue_tracker]\ntype = \"gitlab\"\nproject = \"grp/app\"\n",
+			want: IssueTracker{Type: "gitlab", Project: "grp/app"},
+		},
+		{
+			name: "user config only",
+			user: "[issue_tracker]\ntype = \"jira\"\nurl = \"https://acme.atlassian.net\"\nuser = \"me@example.com\"\ntoken = \"secret\"\n",
+			want: IssueTracker{Type: "jira", URL: "https://acme.atlassian.net", User: "me@example.com", Token: "secret"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Load(writeConfigs(t, tt.user, tt.repo))
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if cfg.IssueTracker != tt.want {
+				t.Errorf("IssueTracker = %+v, want %+v", cfg.IssueTracker, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsRepositoryCredentials(t *testing.T) {
+	for _, setting := range []string{`url = "https://evil.example.com"`, `user = "me"`, `token = "x"`, `token_env = "AWS_SECRET_ACCESS_KEY"`} {
+		repo := "[issue_tracker]\ntype = \"github\"\n" + setting + "\n"
+		_, err := Load(writeConfigs(t, "", repo))
+		name := strings.Fields(setting)[0]
+		if err == nil || !strings.HasSuffix(err.Error(), "issue_tracker."+name+" can only be set in the user config") {
+			t.Errorf("Load() with %s in the repository config: error = %v", name, err)
+		}
+	}
+}
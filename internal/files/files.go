@@ -0,0 +1,185 @@
+package files
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/FScoward/rakutree/internal/config"
+	"github.com/FScoward/rakutree/internal/git"
+)
+
+// Entry is a file or directory of the main worktree to bring into a new one
+type Entry struct {
+	Path    string // Relative to the worktree root
+	Symlink bool   // Link to the original instead of copying it
+}
+
+// Plan lists what a new worktree gets from the main worktree
+type Plan struct {
+	Source  string // Absolute path of the main worktree
+	Entries []Entry
+}
+
+// NewPlan expands the configured patterns against the main worktree at
+// source. Patterns that match nothing are ignored; a file matched by both
+// lists is copied.
+func NewPlan(source string, cfg config.Files) (Plan, error) {
+	plan := Plan{Source: source}
+	seen := make(map[string]bool)
+
+	add := func(patterns []string, symlink bool) error {
+		for _, pattern := range patterns {
+			if filepath.IsAbs(pattern) || escapes(pattern) {
+				return fmt.Errorf("file pattern '%s' must be inside the worktree", pattern)
+			}
+			matches, err := filepath.Glob(filepath.Join(source, pattern))
+			if err != nil {
+				return fmt.Errorf("invalid file pattern '%s': %w", pattern, err)
+			}
+			for _, match := range matches {
+				rel, err := filepath.Rel(source, match)
+				if err != nil || seen[rel] {
+					continue
+				}
+				seen[rel] = true
+				plan.Entries = append(plan.Entries, Entry{Path: rel, Symlink: symlink})
+			}
+		}
+		return nil
+	}
+
+	if err := add(cfg.Copy, false); err != nil {
+		return Plan{}, err
+	}
+	if err := add(cfg.Symlink, true); err != nil {
+		return Plan{}, err
+	}
+	return plan, nil
+}
+
+// escapes reports whether pattern refers to something above the worktree
+func escapes(pattern string) bool {
+	clean := filepath.Clean(pattern)
+	return clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator))
+}
+
+// PlanForRepo returns the plan for a new worktree of repo, sourcing the
+// files from its main worktree
+func PlanForRepo(ctx context.Context, repo *git.Repository) (Plan, error) {
+	cfg := repo.Config().Files
+	if len(cfg.Copy) == 0 && len(cfg.Symlink) == 0 {
+		return Plan{}, nil
+	}
+
+	main, err := repo.MainWorktree(ctx)
+	if err != nil {
+		return Plan{}, err
+	}
+	return NewPlan(main.Path, cfg)
+}
+
+// Summary describes the plan in one line, e.g. "copy .env.local; link certs/dev.pem"
+func (p Plan) Summary() string {
+	var copied, linked []string
+	for _, e := range p.Entries {
+		if e.Symlink {
+			linked = append(linked, e.Path)
+		} else {
+			copied = append(copied, e.Path)
+		}
+	}
+
+	var parts []string
+	if len(copied) > 0 {
+		parts = append(parts, "copy "+strings.Join(copied, ", "))
+	}
+	if len(linked) > 0 {
+		parts = append(parts, "link "+strings.Join(linked, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Apply copies or links the entries into the worktree at dir, reporting
+// each one to out. Files that already exist in dir are left alone.
+func (p Plan) Apply(dir string, out io.Writer) error {
+	for _, e := range p.Entries {
+		src := filepath.Join(p.Source, e.Path)
+		dst := filepath.Join(dir, e.Path)
+
+		if _, err := os.Lstat(dst); err == nil {
+			fmt.Fprintf(out, "Skipped %s (already exists)\n", e.Path)
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", e.Path, err)
+		}
+
+		if e.Symlink {
+			if err := os.Symlink(src, dst); err != nil {
+				return fmt.Errorf("failed to link %s: %w", e.Path, err)
+			}
+			fmt.Fprintf(out, "Linked %s\n", e.Path)
+			continue
+		}
+		if err := copyPath(src, dst); err != nil {
+			return fmt.Errorf("failed to copy %s: %w", e.Path, err)
+		}
+		fmt.Fprintf(out, "Copied %s\n", e.Path)
+	}
+	return nil
+}
+
+// copyPath copies the file or directory tree at src to dst, keeping
+// permissions and recreating symlinks
+func copyPath(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		default:
+			return copyFile(path, target, info.Mode().Perm())
+		}
+	})
+}
+
+func copyFile(src, dst string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
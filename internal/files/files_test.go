@@ -0,0 +1,166 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/FScoward/rakutree/internal/config"
+)
+
+// writeTree creates the files under dir, making their directories
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestNewPlan(t *testing.T) {
+	source := t.TempDir()
+	writeTree(t, source, map[string]string{
+		".env":            "A=1",
+		".env.local":      "B=2",
+		".vscode/a.json":  "{}",
+		"certs/dev.pem":   "pem",
+		"certs/dev.key":   "key",
+		"src/main.go":     "package main",
+		"shared/both.txt": "both",
+	})
+
+	plan, err := NewPlan(source, config.Files{
+		Copy:    []string{".env*", ".vscode", "shared/both.txt", "nomatch/*"},
+		Symlink: []string{"certs/*.pem", ".env.local", "shared/both.txt"},
+	})
+	if err != nil {
+		t.Fatalf("NewPlan() error = %v", err)
+	}
+
+	// Copying wins over linking; patterns matching nothing are ignored
+	want := Plan{Source: source, Entries: []Entry{
+		{Path: ".env"},
+		{Path: ".env.local"},
+		{Path: ".vscode"},
+		{Path: filepath.Join("shared", "both.txt")},
+		{Path: filepath.Join("certs", "dev.pem"), Symlink: true},
+	}}
+	if !reflect.DeepEqual(plan, want) {
+		t.Errorf("NewPlan() = %+v, want %+v", plan, want)
+	}
+	if got, want := plan.Summary(), "copy .env, .env.local, .vscode, shared/both.txt; link certs/dev.pem"; got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+}
+
+func TestNewPlanRejectsPatterns(t *testing.T) {
+	source := t.TempDir()
+	tests := []struct {
+		cfg  config.Files
+		want string
+	}{
+		{config.Files{Copy: []string{"../secrets"}}, "file pattern '../secrets' must be inside the worktree"},
+		{config.Files{Copy: []string{"a/../../secrets"}}, "file pattern 'a/../../secrets' must be inside the worktree"},
+		{config.Files{Copy: []string{".."}}, "file pattern '..' must be inside the worktree"},
+		{config.Files{Symlink: []string{"/etc/passwd"}}, "file pattern '/etc/passwd' must be inside the worktree"},
+		{config.Files{Copy: []string{"[.env"}}, "invalid file pattern '[.env': syntax error in pattern"},
+	}
+	for _, tt := range tests {
+		_, err := NewPlan(source, tt.cfg)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("NewPlan(%+v) error = %v, want %q", tt.cfg, err, tt.want)
+		}
+	}
+
+	// Names that only start with dots stay inside
+	if _, err := NewPlan(source, config.Files{Copy: []string{"..env", "a/../b"}}); err != nil {
+		t.Errorf("NewPlan() error = %v", err)
+	}
+}
+
+func TestApply(t *testing.T) {
+	source := t.TempDir()
+	writeTree(t, source, map[string]string{
+		".env.local":          "B=2",
+		".vscode/a.json":      "{}",
+		".vscode/sub/b.json":  "[]",
+		"certs/dev.pem":       "pem",
+		"scripts/run.sh":      "#!/bin/sh",
+		"existing/config.yml": "from main",
+	})
+	if err := os.Chmod(filepath.Join(source, "scripts", "run.sh"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("a.json", filepath.Join(source, ".vscode", "link.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"existing/config.yml": "from worktree"})
+
+	plan := Plan{Source: source, Entries: []Entry{
+		{Path: ".env.local"},
+		{Path: ".vscode"},
+		{Path: filepath.Join("scripts", "run.sh")},
+		{Path: filepath.Join("existing", "config.yml")},
+		{Path: filepath.Join("certs", "dev.pem"), Symlink: true},
+	}}
+	var out strings.Builder
+	if err := plan.Apply(dir, &out); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	wantOut := "Copied .env.local\nCopied .vscode\nCopied scripts/run.sh\nSkipped existing/config.yml (already exists)\nLinked certs/dev.pem\n"
+	if out.String() != wantOut {
+		t.Errorf("output = %q, want %q", out.String(), wantOut)
+	}
+
+	for name, want := range map[string]string{
+		".env.local":          "B=2",
+		".vscode/a.json":      "{}",
+		".vscode/sub/b.json":  "[]",
+		".vscode/link.json":   "{}",
+		"scripts/run.sh":      "#!/bin/sh",
+		"existing/config.yml": "from worktree",
+		"certs/dev.pem":       "pem",
+	} {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil || string(got) != want {
+			t.Errorf("%s = %q, %v; want %q", name, got, err, want)
+		}
+	}
+
+	// Copies keep their permissions and are not links
+	if info, err := os.Stat(filepath.Join(dir, "scripts", "run.sh")); err != nil || info.Mode().Perm() != 0o755 {
+		t.Errorf("scripts/run.sh mode = %v, %v; want 0755", info.Mode(), err)
+	}
+	if info, err := os.Lstat(filepath.Join(dir, ".env.local")); err != nil || !info.Mode().IsRegular() {
+		t.Errorf(".env.local is not a regular file: %v, %v", info.Mode(), err)
+	}
+
+	// Symlinks inside copied directories are recreated as they were
+	if link, err := os.Readlink(filepath.Join(dir, ".vscode", "link.json")); err != nil || link != "a.json" {
+		t.Errorf(".vscode/link.json links to %q, %v; want a.json", link, err)
+	}
+	// Linked entries point at the main worktree
+	if link, err := os.Readlink(filepath.Join(dir, "certs", "dev.pem")); err != nil || link != filepath.Join(source, "certs", "dev.pem") {
+		t.Errorf("certs/dev.pem links to %q, %v", link, err)
+	}
+}
+
+func TestApplyEmptyPlan(t *testing.T) {
+	var out strings.Builder
+	if err := (Plan{}).Apply(t.TempDir(), &out); err != nil || out.Len() != 0 {
+		t.Errorf("Apply() of an empty plan = %v, output %q", err, out.String())
+	}
+	if got := (Plan{}).Summary(); got != "" {
+		t.Errorf("Summary() of an empty plan = %q", got)
+	}
+}
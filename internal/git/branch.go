@@ -0,0 +1,368 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Branch is a local branch, or a remote-tracking branch that has no local
+// branch of the same name
+type Branch struct {
+	Name       string    // Branch name without the remote, e.g. "feature/x"
+	Remote     string    // Remote of a remote-only branch; empty for local branches
+	IsLocal    bool      // The branch exists locally
+	Upstream   string    // Upstream of a local branch, e.g. "origin/feature/x"
+	LastCommit time.Time // Committer date of the branch tip
+	Author     string    // Author email of the branch tip
+	CheckedOut string    // Path of the worktree the branch is checked out in; empty if none
+}
+
+// Ref returns the name git resolves to the branch: the local name, or
+// "<remote>/<name>" for a remote-only branch
+func (b Branch) Ref() string {
+	if b.IsLocal {
+		return b.Name
+	}
+	return b.Remote + "/" + b.Name
+}
+
+// ListBranches returns the local branches and the branches of every remote
+// that have no local counterpart, most recently committed first
+func (r *Repository) ListBranches(ctx context.Context) ([]Branch, error) {
+	remotes, err := r.Remotes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := r.runner.Run(ctx, "for-each-ref", "--sort=-committerdate",
+		"--format=%(refname)%00%(upstream:short)%00%(committerdate:unix)%00%(symref)%00%(authoremail)",
+		"refs/heads", "refs/remotes")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+	branches := parseBranches(out, remotes)
+
+	worktrees, err := r.ListWorktrees(ctx)
+	if err != nil {
+		return nil, err
+	}
+	markCheckedOut(branches, worktrees)
+
+	return SortBranches(branches, SortRecent, ""), nil
+}
+
+// markCheckedOut sets CheckedOut of the local branches that a worktree has
+// checked out
+func markCheckedOut(branches []Branch, worktrees []Worktree) {
+	paths := make(map[string]string)
+	for _, wt := range worktrees {
+		if wt.Branch != "" {
+			paths[wt.Branch] = wt.Path
+		}
+	}
+	for i := range branches {
+		if branches[i].IsLocal {
+			branches[i].CheckedOut = paths[branches[i].Name]
+		}
+	}
+}
+
+// Remotes returns the names of the configured remotes
+func (r *Repository) Remotes(ctx context.Context) ([]string, error) {
+	out, err := r.runner.Run(ctx, "remote")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list remotes: %w", err)
+	}
+	return strings.Fields(out), nil
+}
+
+// Fetch fetches remote, pruning remote-tracking branches deleted there
+func (r *Repository) Fetch(ctx context.Context, remote string) error {
+	if _, err := r.runner.Run(ctx, "fetch", "--prune", remote); err != nil {
+		return fmt.Errorf("failed to fetch '%s': %w", remote, err)
+	}
+	return nil
+}
+
+// parseBranches parses the for-each-ref output of ListBranches. remotes
+// are needed to split a remote ref, since branch names contain slashes.
+func parseBranches(output string, remotes []string) []Branch {
+	var local, remote []Branch
+	isLocal := make(map[string]bool)
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 5 || fields[3] != "" {
+			// Malformed line or a symbolic ref such as origin/HEAD
+			continue
+		}
+		refname, upstream, date := fields[0], fields[1], fields[2]
+		author := strings.Trim(fields[4], "<>")
+
+		var b Branch
+		if name, ok := strings.CutPrefix(refname, "refs/heads/"); ok {
+			b = Branch{Name: name, IsLocal: true, Upstream: upstream}
+		} else {
+			rest := strings.TrimPrefix(refname, "refs/remotes/")
+			remoteName := matchRemote(rest, remotes)
+			if remoteName == "" {
+				continue
+			}
+			b = Branch{Name: strings.TrimPrefix(rest, remoteName+"/"), Remote: remoteName}
+		}
+		b.Author = author
+		if ts, err := strconv.ParseInt(date, 10, 64); err == nil {
+			b.LastCommit = time.Unix(ts, 0)
+		}
+
+		if b.IsLocal {
+			isLocal[b.Name] = true
+			local = append(local, b)
+		} else {
+			remote = append(remote, b)
+		}
+	}
+
+	branches := local
+	for _, b := range remote {
+		if !isLocal[b.Name] {
+			branches = append(branches, b)
+		}
+	}
+	return branches
+}
+
+// matchRemote returns the longest remote name ref ("<remote>/<branch>")
+// starts with
+func matchRemote(ref string, remotes []string) string {
+	match := ""
+	for _, remote := range remotes {
+		if strings.HasPrefix(ref, remote+"/") && len(remote) > len(match) {
+			match = remote
+		}
+	}
+	return match
+}
+
+// BranchSort is the order branch lists are shown in
+type BranchSort int
+
+const (
+	// SortRecent puts the most recently committed branches first
+	SortRecent BranchSort = iota
+	// SortAlphabetical puts main/master first (main before master), then sorts by name
+	SortAlphabetical
+	// SortMine puts branches whose last commit is the user's first, each group by recency
+	SortMine
+)
+
+func (s BranchSort) String() string {
+	switch s {
+	case SortAlphabetical:
+		return "alphabetical"
+	case SortMine:
+		return "mine first"
+	}
+	return "recent"
+}
+
+// Next returns the sort mode that follows s, wrapping around
+func (s BranchSort) Next() BranchSort {
+	return (s + 1) % 3
+}
+
+// SortBranches sorts branches in place by mode and returns them. email
+// identifies the user's branches for SortMine. Ties are broken by name,
+// local branches before remote ones of the same name.
+func SortBranches(branches []Branch, mode BranchSort, email string) []Branch {
+	priority := func(b Branch) int {
+		switch b.Name {
+		case "main":
+			return 0
+		case "master":
+			return 1
+		}
+		return 2
+	}
+
+	sort.SliceStable(branches, func(i, j int) bool {
+		a, b := branches[i], branches[j]
+		switch mode {
+		case SortAlphabetical:
+			if priority(a) != priority(b) {
+				return priority(a) < priority(b)
+			}
+		case SortMine:
+			if mineA, mineB := a.Author == email, b.Author == email; email != "" && mineA != mineB {
+				return mineA
+			}
+			fallthrough
+		case SortRecent:
+			if !a.LastCommit.Equal(b.LastCommit) {
+				return a.LastCommit.After(b.LastCommit)
+			}
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.IsLocal && !b.IsLocal
+	})
+	return branches
+}
+
+// UserEmail returns the configured user.email, or "" if it is not set
+func (r *Repository) UserEmail(ctx context.Context) string {
+	out, err := r.runner.Run(ctx, "config", "user.email")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
+
+// BranchDeletion selects what happens to a worktree's branch on removal
+type BranchDeletion int
+
+const (
+	// KeepBranch leaves the branch in place
+	KeepBranch BranchDeletion = iota
+	// DeleteMergedBranch deletes the branch only if it is fully merged (git branch -d)
+	DeleteMergedBranch
+	// ForceDeleteBranch deletes the branch even if unmerged commits are lost (git branch -D)
+	ForceDeleteBranch
+)
+
+// DeleteBranch deletes a local branch, refusing unmerged branches unless force is set
+func (r *Repository) DeleteBranch(ctx context.Context, branch string, force bool) error {
+	flag := "-d"
+	if force {
+		flag = "-D"
+	}
+	if _, err := r.runner.Run(ctx, "branch", flag, branch); err != nil {
+		return fmt.Errorf("failed to delete branch '%s': %w", branch, err)
+	}
+	return nil
+}
+
+// DeleteRemoteBranch deletes branch on remote (git push <remote> --delete <branch>)
+func (r *Repository) DeleteRemoteBranch(ctx context.Context, remote, branch string) error {
+	if _, err := r.runner.Run(ctx, "push", remote, "--delete", branch); err != nil {
+		return fmt.Errorf("failed to delete remote branch '%s/%s': %w", remote, branch, err)
+	}
+	return nil
+}
+
+// IsBranchMerged reports whether branch is fully merged into its upstream,
+// or into HEAD when it has none or the upstream is gone, as after a pruning
+// fetch. This is the check 'git branch -d' applies.
+func (r *Repository) IsBranchMerged(ctx context.Context, branch string) (bool, error) {
+	out, err := r.runner.Run(ctx, "for-each-ref", "--format=%(upstream)", "refs/heads/"+branch)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up upstream of '%s': %w", branch, err)
+	}
+
+	target := strings.TrimSpace(out)
+	if target == "" {
+		target = "HEAD"
+	} else if _, err := r.runner.Run(ctx, "rev-parse", "--verify", "--quiet", target+"^{commit}"); err != nil {
+		if exitCode(err) != 1 {
+			return false, fmt.Errorf("failed to look up upstream of '%s': %w", branch, err)
+		}
+		// The upstream was deleted, e.g. by 'git fetch --prune'
+		target = "HEAD"
+	}
+
+	_, err = r.runner.Run(ctx, "merge-base", "--is-ancestor", "refs/heads/"+branch, target)
+	if err == nil {
+		return true, nil
+	}
+	if exitCode(err) == 1 {
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to check whether '%s' is merged: %w", branch, err)
+}
+
+// BranchRemote returns the remote and remote branch name that branch
+// tracks, or empty strings if it has no upstream on a remote or the
+// upstream is gone
+func (r *Repository) BranchRemote(ctx context.Context, branch string) (remote, remoteBranch string, err error) {
+	out, err := r.runner.Run(ctx, "for-each-ref", "--format=%(upstream:remotename)%00%(upstream:remoteref)%00%(upstream:track)", "refs/heads/"+branch)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to look up upstream of '%s': %w", branch, err)
+	}
+
+	fields := strings.Split(strings.TrimSpace(out), "\x00")
+	if len(fields) != 3 {
+		return "", "", nil
+	}
+	remote, ref, track := fields[0], fields[1], fields[2]
+	if remote == "" || remote == "." || ref == "" || track == "[gone]" {
+		return "", "", nil
+	}
+	return remote, strings.TrimPrefix(ref, "refs/heads/"), nil
+}
+
+// exitCode returns the exit status of a failed git command, or -1 if it
+// did not run to completion
+func exitCode(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// MergedBranches returns the set of local branches fully merged into
+// target. A branch that never got commits of its own, such as one just
+// created from target, is not merged: it is where work is about to start.
+func (r *Repository) MergedBranches(ctx context.Context, target string) (map[string]bool, error) {
+	out, err := r.runner.Run(ctx, "branch", "--merged", target, "--format=%(refname:short)%00%(objectname)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches merged into '%s': %w", target, err)
+	}
+	targetTip, err := r.runner.Run(ctx, "rev-parse", "--verify", target+"^{commit}")
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve '%s': %w", target, err)
+	}
+
+	merged := make(map[string]bool)
+	for _, line := range strings.Split(out, "\n") {
+		branch, tip, ok := strings.Cut(strings.TrimSpace(line), "\x00")
+		if !ok {
+			continue
+		}
+		own, err := r.hasOwnCommits(ctx, branch, tip, strings.TrimSpace(targetTip))
+		if err != nil {
+			return nil, err
+		}
+		if own {
+			merged[branch] = true
+		}
+	}
+	return merged, nil
+}
+
+// hasOwnCommits reports whether branch, whose tip is tip, ever had commits
+// of its own. The reflog of a branch that was only created holds nothing
+// but the creation. Without a reflog, a branch still at target's tip is
+// taken to have none.
+func (r *Repository) hasOwnCommits(ctx context.Context, branch, tip, targetTip string) (bool, error) {
+	out, err := r.runner.Run(ctx, "reflog", "show", "--format=%gs", "refs/heads/"+branch, "--")
+	if err != nil {
+		return false, fmt.Errorf("failed to read the reflog of '%s': %w", branch, err)
+	}
+
+	entries := strings.Split(strings.TrimSpace(out), "\n")
+	switch {
+	case entries[0] == "":
+		return tip != targetTip, nil
+	case len(entries) == 1 && strings.HasPrefix(entries[0], "branch: Created from "):
+		return false, nil
+	}
+	return true, nil
+}
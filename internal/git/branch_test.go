@@ -0,0 +1,154 @@
+package git
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMatchRemote(t *testing.T) {
+	remotes := []string{"origin", "upstream", "team", "team/mirror"}
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{"origin/main", "origin"},
+		{"origin/feature/auth", "origin"},
+		{"upstream/fix/x", "upstream"},
+		// Remote names may contain slashes; the longest one wins
+		{"team/mirror/feature/x", "team/mirror"},
+		{"team/feature/x", "team"},
+		{"originals/x", ""},
+		{"unknown/x", ""},
+	}
+	for _, tt := range tests {
+		if got := matchRemote(tt.ref, remotes); got != tt.want {
+			t.Errorf("matchRemote(%q) = %q, want %q", tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestParseBranches(t *testing.T) {
+	lines := []string{
+		"refs/heads/main\x00origin/main\x001700000300\x00\x00<me@example.com>",
+		"refs/heads/feature/auth\x00\x001700000200\x00\x00<me@example.com>",
+		"refs/remotes/origin/HEAD\x00\x001700000300\x00refs/remotes/origin/main\x00<me@example.com>",
+		"refs/remotes/origin/main\x00\x001700000300\x00\x00<me@example.com>",
+		"refs/remotes/origin/fix/login\x00\x001700000100\x00\x00<other@example.com>",
+		"refs/remotes/upstream/fix/login\x00\x001700000050\x00\x00<other@example.com>",
+		"refs/remotes/team/mirror/feature/x\x00\x001700000010\x00\x00<other@example.com>",
+		"refs/remotes/gone/x\x00\x001700000000\x00\x00<other@example.com>",
+		"malformed",
+	}
+
+	got := parseBranches(strings.Join(lines, "\n"), []string{"origin", "upstream", "team", "team/mirror"})
+	want := []Branch{
+		{Name: "main", IsLocal: true, Upstream: "origin/main", LastCommit: time.Unix(1700000300, 0), Author: "me@example.com"},
+		{Name: "feature/auth", IsLocal: true, LastCommit: time.Unix(1700000200, 0), Author: "me@example.com"},
+		// origin/main is hidden by the local main; both remotes of
+		// fix/login are kept
+		{Name: "fix/login", Remote: "origin", LastCommit: time.Unix(1700000100, 0), Author: "other@example.com"},
+		{Name: "fix/login", Remote: "upstream", LastCommit: time.Unix(1700000050, 0), Author: "other@example.com"},
+		{Name: "feature/x", Remote: "team/mirror", LastCommit: time.Unix(1700000010, 0), Author: "other@example.com"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseBranches() =\n%+v\nwant\n%+v", got, want)
+	}
+	if ref := got[3].Ref(); ref != "upstream/fix/login" {
+		t.Errorf("Ref() = %q, want %q", ref, "upstream/fix/login")
+	}
+}
+
+func TestIsBranchMerged(t *testing.T) {
+	const (
+		upstreamCmd = "for-each-ref --format=%(upstream) refs/heads/feature/x"
+		verifyCmd   = "rev-parse --verify --quiet refs/remotes/origin/feature/x^{commit}"
+	)
+
+	tests := []struct {
+		name     string
+		upstream string
+		verify   int // Exit code of rev-parse
+		target   string
+		exit     int // Exit code of merge-base
+		want     bool
+		wantErr  bool
+	}{
+		{name: "merged into upstream", upstream: "refs/remotes/origin/feature/x\n", target: "refs/remotes/origin/feature/x", exit: 0, want: true},
+		{name: "merged into HEAD", upstream: "\n", target: "HEAD", exit: 0, want: true},
+		{name: "not merged", upstream: "\n", target: "HEAD", exit: 1, want: false},
+		// Like 'git branch -d', a pruned upstream falls back to HEAD
+		{name: "upstream gone, merged into HEAD", upstream: "refs/remotes/origin/feature/x\n", verify: 1, target: "HEAD", exit: 0, want: true},
+		{name: "upstream gone, not merged", upstream: "refs/remotes/origin/feature/x\n", verify: 1, target: "HEAD", exit: 1, want: false},
+		{name: "upstream lookup failed", upstream: "refs/remotes/origin/feature/x\n", verify: 128, wantErr: true},
+		{name: "git failed", upstream: "\n", target: "HEAD", exit: 128, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := map[string]fakeResult{upstreamCmd: {out: tt.upstream}}
+			if tt.verify != 0 {
+				results[verifyCmd] = fakeResult{err: exitError(t, tt.verify, strings.Fields(verifyCmd)...)}
+			} else {
+				results[verifyCmd] = fakeResult{out: "1111111111111111111111111111111111111111\n"}
+			}
+			if tt.target != "" {
+				mergeBase := "merge-base --is-ancestor refs/heads/feature/x " + tt.target
+				result := fakeResult{}
+				if tt.exit != 0 {
+					result.err = exitError(t, tt.exit, strings.Fields(mergeBase)...)
+				}
+				results[mergeBase] = result
+			}
+			repo, _ := newFakeRepository(results)
+
+			got, err := repo.IsBranchMerged(context.Background(), "feature/x")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("IsBranchMerged() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("IsBranchMerged() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBranchRemote(t *testing.T) {
+	const remoteCmd = "for-each-ref --format=%(upstream:remotename)%00%(upstream:remoteref)%00%(upstream:track) refs/heads/feature/x"
+
+	tests := []struct {
+		name                 string
+		out                  string
+		remote, remoteBranch string
+	}{
+		{name: "tracked", out: "origin\x00refs/heads/feature/x\x00[ahead 1]\n", remote: "origin", remoteBranch: "feature/x"},
+		{name: "renamed on the remote", out: "team/mirror\x00refs/heads/x\x00\n", remote: "team/mirror", remoteBranch: "x"},
+		{name: "no upstream", out: "\x00\x00\n"},
+		{name: "local upstream", out: ".\x00refs/heads/main\x00\n"},
+		{name: "upstream gone", out: "origin\x00refs/heads/feature/x\x00[gone]\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, _ := newFakeRepository(map[string]fakeResult{remoteCmd: {out: tt.out}})
+			remote, branch, err := repo.BranchRemote(context.Background(), "feature/x")
+			if err != nil {
+				t.Fatalf("BranchRemote() error = %v", err)
+			}
+			if remote != tt.remote || branch != tt.remoteBranch {
+				t.Errorf("BranchRemote() = %q, %q; want %q, %q", remote, branch, tt.remote, tt.remoteBranch)
+			}
+		})
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	if got := exitCode(exitError(t, 1)); got != 1 {
+		t.Errorf("exitCode() = %d, want 1", got)
+	}
+	if got := exitCode(context.Canceled); got != -1 {
+		t.Errorf("exitCode(context.Canceled) = %d, want -1", got)
+	}
+}
@@ -0,0 +1,137 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// StaleOptions controls which worktrees FindStaleWorktrees reports
+type StaleOptions struct {
+	// MaxAge reports worktrees whose last commit is older than this; zero disables the check
+	MaxAge time.Duration
+	// Now is the reference time for MaxAge; zero means time.Now()
+	Now time.Time
+}
+
+// StaleWorktree is a worktree that is a candidate for cleanup
+type StaleWorktree struct {
+	Worktree Worktree
+	Reasons  []string // Why the worktree is considered stale, e.g. "merged into main"
+}
+
+// DefaultBranch returns the repository's default branch: the configured
+// default base, the branch the origin remote's HEAD points to, or else
+// main or master
+func (r *Repository) DefaultBranch(ctx context.Context) (string, error) {
+	if base := r.config.DefaultBase; base != "" && r.refExists(ctx, base+"^{commit}") {
+		return base, nil
+	}
+
+	if out, err := r.runner.Run(ctx, "symbolic-ref", "--quiet", "refs/remotes/origin/HEAD"); err == nil {
+		remoteRef := strings.TrimSpace(out)
+		branch := strings.TrimPrefix(remoteRef, "refs/remotes/origin/")
+		if r.refExists(ctx, "refs/heads/"+branch) {
+			return branch, nil
+		}
+		return strings.TrimPrefix(remoteRef, "refs/remotes/"), nil
+	}
+
+	for _, branch := range []string{"main", "master"} {
+		if r.refExists(ctx, "refs/heads/"+branch) {
+			return branch, nil
+		}
+	}
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	return "", fmt.Errorf("could not determine the default branch (no default_base, origin/HEAD, main or master)")
+}
+
+// refExists reports whether ref resolves
+func (r *Repository) refExists(ctx context.Context, ref string) bool {
+	_, err := r.runner.Run(ctx, "rev-parse", "--verify", "--quiet", ref)
+	return err == nil
+}
+
+// FindStaleWorktrees returns the worktrees that can likely be cleaned up:
+// those whose directory is gone (prunable), whose branch is fully merged
+// into the default branch, or whose last commit is older than MaxAge. The
+// main worktree, bare and locked worktrees are never reported, nor are
+// branches without commits of their own, such as one just created.
+// mergedInto is the default branch; it is empty if there is none, and then
+// no worktree is reported as merged.
+func (r *Repository) FindStaleWorktrees(ctx context.Context, opts StaleOptions) (stale []StaleWorktree, mergedInto string, err error) {
+	worktrees, err := r.ListWorktrees(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	defaultBranch, err := r.DefaultBranch(ctx)
+	if ctx.Err() != nil {
+		return nil, "", ctx.Err()
+	}
+	var merged map[string]bool
+	if err == nil {
+		merged, err = r.MergedBranches(ctx, defaultBranch)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	if len(worktrees) <= 1 {
+		return nil, defaultBranch, nil
+	}
+
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	for _, wt := range worktrees[1:] {
+		if wt.IsBare || wt.Locked {
+			continue
+		}
+
+		var reasons []string
+		if wt.Prunable {
+			reasons = append(reasons, "directory is missing")
+		}
+		if wt.Branch != "" && wt.Branch != defaultBranch && merged[wt.Branch] {
+			reasons = append(reasons, fmt.Sprintf("merged into %s", defaultBranch))
+		}
+		if opts.MaxAge > 0 && !wt.Prunable {
+			last, err := r.lastCommitTime(ctx, wt.Path)
+			if err != nil {
+				return nil, "", err
+			}
+			if !last.IsZero() && now.Sub(last) > opts.MaxAge {
+				reasons = append(reasons, fmt.Sprintf("last commit %s", RelativeAge(last, now)))
+			}
+		}
+
+		if len(reasons) > 0 {
+			stale = append(stale, StaleWorktree{Worktree: wt, Reasons: reasons})
+		}
+	}
+
+	return stale, defaultBranch, nil
+}
+
+// lastCommitTime returns the committer date of HEAD in the worktree at
+// path, or the zero time if it has no commits
+func (r *Repository) lastCommitTime(ctx context.Context, path string) (time.Time, error) {
+	out, err := r.runner.Run(ctx, "-C", path, "log", "-1", "--format=%ct")
+	if err != nil {
+		if ctx.Err() != nil {
+			return time.Time{}, fmt.Errorf("failed to get last commit of %s: %w", path, err)
+		}
+		return time.Time{}, nil
+	}
+
+	var ts int64
+	if _, err := fmt.Sscan(strings.TrimSpace(out), &ts); err != nil {
+		return time.Time{}, nil
+	}
+	return time.Unix(ts, 0), nil
+}
@@ -0,0 +1,192 @@
+package git
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/FScoward/rakutree/internal/config"
+)
+
+const (
+	mainTip = "1111111111111111111111111111111111111111"
+	oldTip  = "2222222222222222222222222222222222222222"
+)
+
+func TestFindStaleWorktrees(t *testing.T) {
+	repo, _ := newFakeRepository(map[string]fakeResult{
+		"worktree list --porcelain": {out: `worktree /src/app
+HEAD ` + mainTip + `
+branch refs/heads/main
+
+worktree /src/wt/new
+HEAD ` + mainTip + `
+branch refs/heads/feature/new
+
+worktree /src/wt/new-old-base
+HEAD ` + oldTip + `
+branch refs/heads/feature/new-old-base
+
+worktree /src/wt/done
+HEAD ` + oldTip + `
+branch refs/heads/feature/done
+
+worktree /src/wt/no-reflog
+HEAD ` + mainTip + `
+branch refs/heads/feature/no-reflog
+
+worktree /src/wt/merged-no-reflog
+HEAD ` + oldTip + `
+branch refs/heads/feature/merged-no-reflog
+
+worktree /src/wt/locked
+HEAD ` + oldTip + `
+branch refs/heads/feature/locked
+locked
+
+worktree /src/wt/gone
+HEAD ` + mainTip + `
+branch refs/heads/feature/gone
+prunable gitdir file points to non-existent location
+`},
+		"rev-parse --verify --quiet refs/heads/main": {out: mainTip + "\n"},
+		"branch --merged main --format=%(refname:short)%00%(objectname)": {out: "main\x00" + mainTip + "\n" +
+			"feature/new\x00" + mainTip + "\n" +
+			"feature/new-old-base\x00" + oldTip + "\n" +
+			"feature/done\x00" + oldTip + "\n" +
+			"feature/no-reflog\x00" + mainTip + "\n" +
+			"feature/merged-no-reflog\x00" + oldTip + "\n" +
+			"feature/locked\x00" + oldTip + "\n" +
+			"feature/gone\x00" + mainTip + "\n"},
+		"rev-parse --verify main^{commit}":                                {out: mainTip + "\n"},
+		"reflog show --format=%gs refs/heads/main --":                     {out: "commit: b\ncommit (initial): a\n"},
+		"reflog show --format=%gs refs/heads/feature/new --":              {out: "branch: Created from HEAD\n"},
+		"reflog show --format=%gs refs/heads/feature/new-old-base --":     {out: "branch: Created from main\n"},
+		"reflog show --format=%gs refs/heads/feature/done --":             {out: "commit: fix\nbranch: Created from main\n"},
+		"reflog show --format=%gs refs/heads/feature/no-reflog --":        {},
+		"reflog show --format=%gs refs/heads/feature/merged-no-reflog --": {},
+		"reflog show --format=%gs refs/heads/feature/locked --":           {out: "commit: x\nbranch: Created from main\n"},
+		"reflog show --format=%gs refs/heads/feature/gone --":             {out: "branch: Created from HEAD\n"},
+	})
+
+	stale, mergedInto, err := repo.FindStaleWorktrees(context.Background(), StaleOptions{})
+	if err != nil {
+		t.Fatalf("FindStaleWorktrees() error = %v", err)
+	}
+	if mergedInto != "main" {
+		t.Errorf("FindStaleWorktrees() merged into %q, want main", mergedInto)
+	}
+
+	got := make(map[string][]string)
+	for _, s := range stale {
+		got[s.Worktree.Path] = s.Reasons
+	}
+	want := map[string][]string{
+		"/src/wt/done":             {"merged into main"},
+		"/src/wt/merged-no-reflog": {"merged into main"},
+		"/src/wt/gone":             {"directory is missing"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindStaleWorktrees() = %v, want %v", got, want)
+	}
+}
+
+func TestFindStaleWorktreesWithoutDefaultBranch(t *testing.T) {
+	repo, _ := newFakeRepository(map[string]fakeResult{
+		"worktree list --porcelain": {out: `worktree /src/app
+HEAD ` + mainTip + `
+branch refs/heads/trunk
+
+worktree /src/wt/done
+HEAD ` + oldTip + `
+branch refs/heads/feature/done
+
+worktree /src/wt/gone
+HEAD ` + mainTip + `
+branch refs/heads/feature/gone
+prunable gitdir file points to non-existent location
+`},
+	})
+
+	// Missing directories are still found
+	stale, mergedInto, err := repo.FindStaleWorktrees(context.Background(), StaleOptions{})
+	if err != nil {
+		t.Fatalf("FindStaleWorktrees() error = %v", err)
+	}
+	if mergedInto != "" || len(stale) != 1 || stale[0].Worktree.Path != "/src/wt/gone" {
+		t.Errorf("FindStaleWorktrees() = %+v, %q; want only the missing worktree", stale, mergedInto)
+	}
+}
+
+func TestDefaultBranch(t *testing.T) {
+	tests := []struct {
+		name    string
+		base    string
+		results map[string]fakeResult
+		want    string
+	}{
+		{
+			name: "configured base",
+			base: "develop",
+			results: map[string]fakeResult{
+				"rev-parse --verify --quiet develop^{commit}": {out: mainTip + "\n"},
+			},
+			want: "develop",
+		},
+		{
+			name: "configured base is missing",
+			base: "develop",
+			results: map[string]fakeResult{
+				"symbolic-ref --quiet refs/remotes/origin/HEAD": {out: "refs/remotes/origin/trunk\n"},
+				"rev-parse --verify --quiet refs/heads/trunk":   {out: mainTip + "\n"},
+			},
+			want: "trunk",
+		},
+		{
+			name: "origin HEAD without a local branch",
+			results: map[string]fakeResult{
+				"symbolic-ref --quiet refs/remotes/origin/HEAD": {out: "refs/remotes/origin/trunk\n"},
+			},
+			want: "origin/trunk",
+		},
+		{
+			name: "master",
+			results: map[string]fakeResult{
+				"rev-parse --verify --quiet refs/heads/master": {out: mainTip + "\n"},
+			},
+			want: "master",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, _ := newFakeRepository(tt.results)
+			repo.SetConfig(config.Config{DefaultBase: tt.base})
+			got, err := repo.DefaultBranch(context.Background())
+			if err != nil {
+				t.Fatalf("DefaultBranch() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("DefaultBranch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergedBranchesSkipsNewBranches(t *testing.T) {
+	// Right after 'git worktree add ../wt -b feature/one'
+	repo, _ := newFakeRepository(map[string]fakeResult{
+		"branch --merged main --format=%(refname:short)%00%(objectname)": {out: "feature/one\x00" + mainTip + "\nmain\x00" + mainTip + "\n"},
+		"rev-parse --verify main^{commit}":                               {out: mainTip + "\n"},
+		"reflog show --format=%gs refs/heads/feature/one --":             {out: "branch: Created from HEAD\n"},
+		"reflog show --format=%gs refs/heads/main --":                    {out: "commit (initial): a\n"},
+	})
+
+	merged, err := repo.MergedBranches(context.Background(), "main")
+	if err != nil {
+		t.Fatalf("MergedBranches() error = %v", err)
+	}
+	if want := map[string]bool{"main": true}; !reflect.DeepEqual(merged, want) {
+		t.Errorf("MergedBranches() = %v, want %v", merged, want)
+	}
+}
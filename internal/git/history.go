@@ -0,0 +1,88 @@
+package git
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/FScoward/rakutree/internal/history"
+)
+
+// History returns the remembered choices the suggestions are ranked by;
+// nil if there are none
+func (r *Repository) History() *history.History {
+	return r.history
+}
+
+// SetHistory sets the remembered choices the suggestions are ranked by
+func (r *Repository) SetHistory(h *history.History) {
+	r.history = h
+}
+
+// RememberWorktree records the choices behind a newly created worktree so
+// they are suggested first next time: the path template it was created
+// from ("" if unknown) and, if the branch was created with it (isNew), the
+// branch's prefix and the base it was created from ("" if not chosen).
+func (r *Repository) RememberWorktree(template, branch string, isNew bool, base string) error {
+	if r.history == nil {
+		return nil
+	}
+	now := time.Now()
+	r.history.Record(history.Paths, template, now)
+	if isNew {
+		if i := strings.LastIndex(branch, "/"); i > 0 {
+			r.history.Record(history.Prefixes, branch[:i+1], now)
+		}
+		r.history.Record(history.Bases, base, now)
+	}
+	return r.history.Save()
+}
+
+// PathTemplate returns the template that produces path, which may be
+// relative to the repository directory, for branch, e.g.
+// "/src/wt/{branch|slug}" for "/src/wt/feature-auth"; "" if the path does
+// not contain the branch
+func (r *Repository) PathTemplate(path, branch string) string {
+	abs, err := r.AbsPath(path)
+	if err != nil {
+		return ""
+	}
+	matches := extractPatterns(abs, branch)
+	if len(matches) == 0 {
+		return ""
+	}
+	return strings.Replace(matches[0].shape, "\x00", matches[0].strategy, 1)
+}
+
+// rankPathsByHistory adds the remembered path templates that are not
+// suggested yet and moves the suggestions chosen before to the top, by
+// frecency
+func (r *Repository) rankPathsByHistory(suggestions []PathSuggestion, vars TemplateVars, seen map[string]bool, key func(string) string) []PathSuggestion {
+	now := time.Now()
+	for _, e := range r.history.Entries(history.Paths, now) {
+		path, err := ExpandTemplate(e.Value, vars)
+		if err != nil || seen[key(path)] {
+			continue
+		}
+		path = r.relPath(path)
+		seen[key(path)] = true
+		suggestions = append(suggestions, PathSuggestion{
+			Path:        path,
+			Description: "Remembered template " + r.relPath(e.Value),
+			IsCustom:    false,
+			Template:    e.Value,
+		})
+	}
+
+	for i, sug := range suggestions {
+		if e, ok := r.history.Lookup(history.Paths, sug.Template); ok {
+			suggestions[i].Description = fmt.Sprintf("%s, used %s", sug.Description, plural(e.Count, "time"))
+		}
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return r.history.Frecency(history.Paths, suggestions[i].Template, now) >
+			r.history.Frecency(history.Paths, suggestions[j].Template, now)
+	})
+	return suggestions
+}
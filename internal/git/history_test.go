@@ -0,0 +1,65 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/FScoward/rakutree/internal/history"
+)
+
+func TestRememberWorktree(t *testing.T) {
+	tests := []struct {
+		name         string
+		branch       string
+		isNew        bool
+		base         string
+		wantPrefixes []string
+		wantBases    []string
+	}{
+		{name: "new branch", branch: "feature/x", isNew: true, base: "develop", wantPrefixes: []string{"feature/"}, wantBases: []string{"develop"}},
+		// 'rtr add -b feature/x' without --base
+		{name: "new branch, default base", branch: "feature/x", isNew: true, wantPrefixes: []string{"feature/"}},
+		{name: "new branch without prefix", branch: "hotfix", isNew: true, base: "main", wantBases: []string{"main"}},
+		{name: "existing branch", branch: "feature/x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("XDG_STATE_HOME", t.TempDir())
+			h, err := history.Load("/src/app/.git")
+			if err != nil {
+				t.Fatal(err)
+			}
+			repo, _ := newFakeRepository(nil)
+			repo.SetHistory(h)
+
+			if err := repo.RememberWorktree("/src/wt/{branch|slug}", tt.branch, tt.isNew, tt.base); err != nil {
+				t.Fatalf("RememberWorktree() error = %v", err)
+			}
+
+			// Read back what was saved
+			saved, err := history.Load("/src/app/.git")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := saved.Lookup(history.Paths, "/src/wt/{branch|slug}"); !ok {
+				t.Error("path template was not remembered")
+			}
+			if got := values(saved, history.Prefixes); !reflect.DeepEqual(got, tt.wantPrefixes) {
+				t.Errorf("prefixes = %q, want %q", got, tt.wantPrefixes)
+			}
+			if got := values(saved, history.Bases); !reflect.DeepEqual(got, tt.wantBases) {
+				t.Errorf("bases = %q, want %q", got, tt.wantBases)
+			}
+		})
+	}
+}
+
+func values(h *history.History, kind history.Kind) []string {
+	var values []string
+	for _, e := range h.Entries(kind, time.Now()) {
+		values = append(values, e.Value)
+	}
+	return values
+}
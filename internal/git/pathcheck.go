@@ -0,0 +1,116 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxAlternatives bounds the numbered paths tried for a free alternative
+const maxAlternatives = 99
+
+// pathChecker tells whether paths are good places for a new worktree
+type pathChecker struct {
+	worktrees []Worktree // With symlinks resolved; the main worktree first
+	commonDir string
+}
+
+func newPathChecker(worktrees []Worktree, commonDir string) pathChecker {
+	resolved := make([]Worktree, len(worktrees))
+	for i, wt := range worktrees {
+		wt.Path = realPath(wt.Path)
+		resolved[i] = wt
+	}
+	return pathChecker{worktrees: resolved, commonDir: realPath(commonDir)}
+}
+
+// check returns why the absolute path is a poor place for a new worktree,
+// or "" if it is free. git refuses existing files, non-empty directories
+// and registered worktrees; a worktree nested inside another one or inside
+// the git directory works but confuses both.
+func (c pathChecker) check(path string) string {
+	path = realPath(path)
+
+	for _, wt := range c.worktrees {
+		if wt.Path != path {
+			continue
+		}
+		if wt.Branch != "" {
+			return fmt.Sprintf("already the worktree of '%s'", wt.Branch)
+		}
+		return "already a worktree"
+	}
+
+	if info, err := os.Stat(path); err == nil {
+		if !info.IsDir() {
+			return "a file exists there"
+		}
+		if entries, err := os.ReadDir(path); err != nil || len(entries) > 0 {
+			return "directory exists and is not empty"
+		}
+	}
+
+	if isInside(path, c.commonDir) {
+		return "inside the git directory"
+	}
+	for i, wt := range c.worktrees {
+		if !isInside(path, wt.Path) {
+			continue
+		}
+		if i == 0 {
+			return "inside the main worktree"
+		}
+		return "inside the worktree at " + wt.Path
+	}
+	return ""
+}
+
+// alternative returns the first of path-2, path-3, ... that is free, or
+// "" if none is
+func (c pathChecker) alternative(path string) string {
+	for i := 2; i <= maxAlternatives; i++ {
+		candidate := fmt.Sprintf("%s-%d", path, i)
+		if c.check(candidate) == "" {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// missingParent returns the outermost directory above the absolute path
+// that does not exist yet, which 'git worktree add' would create, or "" if
+// the parent directory exists
+func missingParent(path string) string {
+	missing := ""
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(dir); err == nil {
+			return missing
+		}
+		missing = dir
+		if filepath.Dir(dir) == dir {
+			return missing
+		}
+	}
+}
+
+// isInside reports whether path is below dir
+func isInside(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// realPath resolves symlinks in the part of path that exists, so paths
+// compare equal however they were reached
+func realPath(path string) string {
+	rest := ""
+	for dir := path; ; dir = filepath.Dir(dir) {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(resolved, rest)
+		}
+		if filepath.Dir(dir) == dir {
+			return path
+		}
+		rest = filepath.Join(filepath.Base(dir), rest)
+	}
+}
@@ -0,0 +1,174 @@
+package git
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/FScoward/rakutree/internal/config"
+	"github.com/FScoward/rakutree/internal/history"
+)
+
+// minLearnedBranches is how many branches must share a prefix that is not
+// configured before it is suggested
+const minLearnedBranches = 2
+
+// prefixRank is what the position of a branch prefix in the suggestions
+// is decided by
+type prefixRank struct {
+	Name        string
+	Description string
+	Frecency    float64   // Of the times the prefix was chosen before
+	Used        int       // How often the prefix was chosen before
+	Branches    int       // Branches using the prefix
+	Latest      time.Time // Newest commit on those branches
+	Priority    int       // Position in the config or the built-in list; -1 if in neither
+}
+
+// analyzeBranchPrefixes collects the prefixes of branches, counting a
+// branch for every level of a multi-level prefix: "team/feature/x" counts
+// for "team/" and "team/feature/"
+func analyzeBranchPrefixes(branches []Branch) map[string]*prefixRank {
+	ranks := make(map[string]*prefixRank)
+
+	for _, b := range branches {
+		// Skip main/master branches
+		if b.Name == "main" || b.Name == "master" {
+			continue
+		}
+
+		for i := 0; i < len(b.Name); i++ {
+			if b.Name[i] != '/' || i == 0 {
+				continue
+			}
+			prefix := b.Name[:i+1]
+			rank := ranks[prefix]
+			if rank == nil {
+				rank = &prefixRank{Name: prefix, Priority: -1}
+				ranks[prefix] = rank
+			}
+			rank.Branches++
+			if b.LastCommit.After(rank.Latest) {
+				rank.Latest = b.LastCommit
+			}
+		}
+	}
+
+	return ranks
+}
+
+// rankPrefixes decides which prefixes are suggested and in which order.
+// Prefixes declared in the config replace the built-in ones and are then
+// the only ones suggested; otherwise learned prefixes shared by enough
+// branches and prefixes chosen before are added to the built-in ones.
+func rankPrefixes(learned map[string]*prefixRank, declared []config.Prefix, configured bool, h *history.History, now time.Time) []prefixRank {
+	ranks := make(map[string]*prefixRank)
+	get := func(name string) *prefixRank {
+		if rank := ranks[name]; rank != nil {
+			return rank
+		}
+		rank := &prefixRank{Name: name, Priority: -1}
+		if l := learned[name]; l != nil {
+			*rank = *l
+		}
+		ranks[name] = rank
+		return rank
+	}
+
+	for i, p := range declared {
+		rank := get(p.Name)
+		rank.Description = p.Description
+		rank.Priority = i
+	}
+	if !configured {
+		for name, l := range learned {
+			if l.Branches >= minLearnedBranches && !coveredByChild(name, learned) {
+				get(name)
+			}
+		}
+		for _, e := range h.Entries(history.Prefixes, now) {
+			get(e.Value)
+		}
+	}
+
+	var sorted []prefixRank
+	for _, rank := range ranks {
+		if e, ok := h.Lookup(history.Prefixes, rank.Name); ok {
+			rank.Used = e.Count
+			rank.Frecency = e.Frecency(now)
+		}
+		sorted = append(sorted, *rank)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		switch {
+		case a.Frecency != b.Frecency:
+			return a.Frecency > b.Frecency
+		case a.Branches != b.Branches:
+			return a.Branches > b.Branches
+		case !a.Latest.Equal(b.Latest):
+			return a.Latest.After(b.Latest)
+		case a.Priority != b.Priority:
+			// Declared prefixes keep their order, ahead of the others
+			return b.Priority < 0 || (a.Priority >= 0 && a.Priority < b.Priority)
+		case strings.Count(a.Name, "/") != strings.Count(b.Name, "/"):
+			// The more specific prefix saves more typing
+			return strings.Count(a.Name, "/") > strings.Count(b.Name, "/")
+		}
+		return a.Name < b.Name
+	})
+	return sorted
+}
+
+// coveredByChild reports whether all branches with the learned prefix
+// name share a longer prefix, e.g. "team/" when every team branch is
+// under "team/feature/", making name not worth suggesting
+func coveredByChild(name string, learned map[string]*prefixRank) bool {
+	for child, l := range learned {
+		if child != name && strings.HasPrefix(child, name) && l.Branches == learned[name].Branches {
+			return true
+		}
+	}
+	return false
+}
+
+// reason explains the rank of the prefix in the order the signals count
+func (p prefixRank) reason(now time.Time) string {
+	var parts []string
+	if p.Used > 0 {
+		parts = append(parts, "chosen "+plural(p.Used, "time"))
+	}
+	if p.Branches > 0 {
+		if p.Branches == 1 {
+			parts = append(parts, "1 branch")
+		} else {
+			parts = append(parts, fmt.Sprintf("%d branches", p.Branches))
+		}
+		if age := RelativeAge(p.Latest, now); age != "" {
+			parts = append(parts, "latest "+age)
+		}
+	}
+	if len(parts) == 0 && p.Priority >= 0 {
+		parts = append(parts, "not used yet")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// suggestion renders the ranked prefix, e.g. "New feature (12 branches,
+// latest 2 days ago)"
+func (p prefixRank) suggestion(now time.Time) BranchNameSuggestion {
+	desc := p.Description
+	if desc == "" {
+		desc = "Learned pattern"
+	}
+	if reason := p.reason(now); reason != "" {
+		desc = fmt.Sprintf("%s (%s)", desc, reason)
+	}
+	return BranchNameSuggestion{
+		Name:        p.Name,
+		Description: desc,
+		IsCustom:    false,
+	}
+}
@@ -0,0 +1,240 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/FScoward/rakutree/internal/config"
+	"github.com/FScoward/rakutree/internal/history"
+)
+
+var rankNow = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// branchesAt returns local branches whose tips are the given ages old
+func branchesAt(ages map[string]time.Duration) []Branch {
+	var branches []Branch
+	for name, age := range ages {
+		branches = append(branches, Branch{Name: name, IsLocal: true, LastCommit: rankNow.Add(-age)})
+	}
+	return branches
+}
+
+func TestAnalyzeBranchPrefixes(t *testing.T) {
+	ranks := analyzeBranchPrefixes(branchesAt(map[string]time.Duration{
+		"main":                 time.Hour,
+		"team/feature/login":   3 * time.Hour,
+		"team/feature/signup":  2 * time.Hour,
+		"team/fix/crash":       5 * time.Hour,
+		"feature/x":            24 * time.Hour,
+		"/leading-slash":       time.Hour,
+		"no-prefix":            time.Hour,
+		"release/1.0/hotfix/a": 48 * time.Hour,
+	}))
+
+	// Every level of a multi-level prefix counts
+	want := map[string]prefixRank{
+		"team/":               {Name: "team/", Branches: 3, Latest: rankNow.Add(-2 * time.Hour), Priority: -1},
+		"team/feature/":       {Name: "team/feature/", Branches: 2, Latest: rankNow.Add(-2 * time.Hour), Priority: -1},
+		"team/fix/":           {Name: "team/fix/", Branches: 1, Latest: rankNow.Add(-5 * time.Hour), Priority: -1},
+		"feature/":            {Name: "feature/", Branches: 1, Latest: rankNow.Add(-24 * time.Hour), Priority: -1},
+		"release/":            {Name: "release/", Branches: 1, Latest: rankNow.Add(-48 * time.Hour), Priority: -1},
+		"release/1.0/":        {Name: "release/1.0/", Branches: 1, Latest: rankNow.Add(-48 * time.Hour), Priority: -1},
+		"release/1.0/hotfix/": {Name: "release/1.0/hotfix/", Branches: 1, Latest: rankNow.Add(-48 * time.Hour), Priority: -1},
+	}
+	got := make(map[string]prefixRank)
+	for name, rank := range ranks {
+		got[name] = *rank
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("analyzeBranchPrefixes() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestCoveredByChild(t *testing.T) {
+	learned := analyzeBranchPrefixes(branchesAt(map[string]time.Duration{
+		"team/feature/login":  time.Hour,
+		"team/feature/signup": time.Hour,
+		"ops/infra/dns":       time.Hour,
+		"ops/infra/tls":       time.Hour,
+		"ops/oncall":          time.Hour,
+	}))
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"team/", true},          // Every team branch is under team/feature/
+		{"team/feature/", false}, // Nothing longer
+		{"ops/", false},          // ops/oncall is not under ops/infra/
+		{"ops/infra/", false},
+	}
+	for _, tt := range tests {
+		if got := coveredByChild(tt.name, learned); got != tt.want {
+			t.Errorf("coveredByChild(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// testHistory returns a history in which each prefix was chosen at the
+// given times
+func testHistory(t *testing.T, chosen map[string][]time.Time) *history.History {
+	t.Helper()
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	h, err := history.Load("/src/app/.git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for prefix, times := range chosen {
+		for _, at := range times {
+			h.Record(history.Prefixes, prefix, at)
+		}
+	}
+	return h
+}
+
+func TestRankPrefixes(t *testing.T) {
+	declared := []config.Prefix{
+		{Name: "feature/", Description: "New feature"},
+		{Name: "fix/", Description: "Bug fix"},
+		{Name: "docs/", Description: "Documentation"},
+	}
+
+	tests := []struct {
+		name       string
+		branches   map[string]time.Duration
+		declared   []config.Prefix
+		configured bool
+		chosen     map[string][]time.Time
+		want       []string
+	}{
+		{
+			name:     "no signals keeps the declared order",
+			declared: declared,
+			want:     []string{"feature/", "fix/", "docs/"},
+		},
+		{
+			name:     "more branches first",
+			branches: map[string]time.Duration{"docs/a": time.Hour, "docs/b": time.Hour, "fix/a": time.Hour},
+			declared: declared,
+			want:     []string{"docs/", "fix/", "feature/"},
+		},
+		{
+			name:     "equal branches, newest first",
+			branches: map[string]time.Duration{"docs/a": time.Hour, "fix/a": 2 * time.Hour},
+			declared: declared,
+			want:     []string{"docs/", "fix/", "feature/"},
+		},
+		{
+			name:     "full tie, declared before learned",
+			branches: map[string]time.Duration{"fix/a": time.Hour, "fix/b": time.Hour, "wip/a": time.Hour, "wip/b": time.Hour},
+			declared: declared,
+			want:     []string{"fix/", "wip/", "feature/", "docs/"},
+		},
+		{
+			name:     "full tie of learned, by name",
+			branches: map[string]time.Duration{"b/x/1": time.Hour, "b/y/2": time.Hour, "a/1": time.Hour, "a/2": time.Hour, "c/1": time.Hour, "c/2": time.Hour},
+			want:     []string{"a/", "b/", "c/"},
+		},
+		{
+			name:     "full tie of learned, deeper first",
+			branches: map[string]time.Duration{"wip/1": time.Hour, "wip/2": time.Hour, "ops/infra/1": time.Hour, "ops/infra/2": time.Hour, "ops/oncall": time.Hour},
+			want:     []string{"ops/", "ops/infra/", "wip/"},
+		},
+		{
+			name:     "multi-level prefixes",
+			branches: map[string]time.Duration{"team/feature/a": time.Hour, "team/feature/b": time.Hour, "team/fix/a": time.Hour, "team/fix/b": time.Hour},
+			declared: declared,
+			// team/ has more branches than each of its children
+			want: []string{"team/", "team/feature/", "team/fix/", "feature/", "fix/", "docs/"},
+		},
+		{
+			name:     "covered prefix is left out",
+			branches: map[string]time.Duration{"team/feature/a": time.Hour, "team/feature/b": time.Hour},
+			want:     []string{"team/feature/"},
+		},
+		{
+			name:     "a single branch is not enough to learn from",
+			branches: map[string]time.Duration{"once/a": time.Hour},
+			declared: declared,
+			want:     []string{"feature/", "fix/", "docs/"},
+		},
+		{
+			name:       "configured prefixes only",
+			branches:   map[string]time.Duration{"wip/a": time.Hour, "wip/b": time.Hour, "docs/a": time.Hour},
+			declared:   declared,
+			configured: true,
+			chosen:     map[string][]time.Time{"other/": {rankNow}},
+			want:       []string{"docs/", "feature/", "fix/"},
+		},
+		{
+			name:     "chosen before beats more branches",
+			branches: map[string]time.Duration{"fix/a": time.Hour, "fix/b": time.Hour, "fix/c": time.Hour},
+			declared: declared,
+			chosen:   map[string][]time.Time{"docs/": {rankNow.Add(-time.Hour)}},
+			want:     []string{"docs/", "fix/", "feature/"},
+		},
+		{
+			name:     "recent choices beat old ones",
+			declared: declared,
+			chosen: map[string][]time.Time{
+				"feature/": {rankNow.Add(-60 * 24 * time.Hour), rankNow.Add(-59 * 24 * time.Hour), rankNow.Add(-58 * 24 * time.Hour)},
+				"fix/":     {rankNow.Add(-time.Hour)},
+			},
+			// 3 old choices score 1.5, one of today 4
+			want: []string{"fix/", "feature/", "docs/"},
+		},
+		{
+			name:   "chosen prefixes are suggested without branches",
+			chosen: map[string][]time.Time{"spike/": {rankNow}},
+			want:   []string{"spike/"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := testHistory(t, tt.chosen)
+			ranks := rankPrefixes(analyzeBranchPrefixes(branchesAt(tt.branches)), tt.declared, tt.configured, h, rankNow)
+
+			var got []string
+			for _, rank := range ranks {
+				got = append(got, rank.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rankPrefixes() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRankPrefixesIsStable(t *testing.T) {
+	branches := map[string]time.Duration{"a/1": time.Hour, "a/2": time.Hour, "b/1": time.Hour, "b/2": time.Hour, "c/x/1": time.Hour, "c/y/1": time.Hour}
+	h := testHistory(t, nil)
+
+	first := rankPrefixes(analyzeBranchPrefixes(branchesAt(branches)), commonPrefixes, false, h, rankNow)
+	for i := 0; i < 20; i++ {
+		// Map iteration order differs between runs
+		again := rankPrefixes(analyzeBranchPrefixes(branchesAt(branches)), commonPrefixes, false, h, rankNow)
+		if !reflect.DeepEqual(again, first) {
+			t.Fatalf("rankPrefixes() changed order:\n%+v\n%+v", first, again)
+		}
+	}
+}
+
+func TestPrefixRankReason(t *testing.T) {
+	tests := []struct {
+		rank prefixRank
+		want string
+	}{
+		{prefixRank{Used: 2, Branches: 12, Latest: rankNow.Add(-48 * time.Hour)}, "chosen 2 times, 12 branches, latest 2 days ago"},
+		{prefixRank{Branches: 1, Latest: rankNow.Add(-48 * time.Hour)}, "1 branch, latest 2 days ago"},
+		{prefixRank{Used: 1, Priority: -1}, "chosen 1 time"},
+		{prefixRank{Priority: 0}, "not used yet"},
+		{prefixRank{Priority: -1}, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.rank.reason(rankNow); got != tt.want {
+			t.Errorf("reason() = %q, want %q", got, tt.want)
+		}
+	}
+}
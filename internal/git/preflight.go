@@ -0,0 +1,164 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// RemovalPreflight reports what would be lost by removing a worktree
+type RemovalPreflight struct {
+	Worktree Worktree
+	Status   WorktreeStatus
+	Unpushed int      // Commits not yet on any remote or other branch
+	Stashes  []string // Stash entries (e.g. "stash@{0}: On feature/x: wip") made on the branch
+
+	BranchMerged bool   // The branch can be deleted with 'git branch -d'
+	Remote       string // Remote the branch tracks; empty if none
+	RemoteBranch string // Name of the tracked branch on Remote
+}
+
+// NeedsForce reports whether git will refuse the removal without --force
+func (p RemovalPreflight) NeedsForce() bool {
+	return p.Status.IsDirty() || p.Worktree.Locked
+}
+
+// IsSafe reports whether the worktree can be removed without losing anything
+func (p RemovalPreflight) IsSafe() bool {
+	return !p.NeedsForce() && p.Unpushed == 0 && len(p.Stashes) == 0
+}
+
+// Risks returns human-readable descriptions of what removal would lose or
+// what prevents it
+func (p RemovalPreflight) Risks() []string {
+	var risks []string
+	if p.Worktree.Locked {
+		if p.Worktree.LockReason != "" {
+			risks = append(risks, fmt.Sprintf("Worktree is locked: %s", p.Worktree.LockReason))
+		} else {
+			risks = append(risks, "Worktree is locked")
+		}
+	}
+	if n := p.Status.Staged + p.Status.Unstaged + p.Status.Conflicted; n > 0 {
+		risks = append(risks, fmt.Sprintf("%s with uncommitted changes will be lost", plural(n, "file")))
+	}
+	if p.Status.Untracked > 0 {
+		risks = append(risks, fmt.Sprintf("%s will be deleted", plural(p.Status.Untracked, "untracked file")))
+	}
+	if p.Unpushed > 0 {
+		if p.Worktree.Branch == "" {
+			risks = append(risks, fmt.Sprintf("%s on the detached HEAD are not on any branch or remote", plural(p.Unpushed, "commit")))
+		} else {
+			risks = append(risks, fmt.Sprintf("%s on '%s' not pushed to any remote", plural(p.Unpushed, "commit"), p.Worktree.Branch))
+		}
+	}
+	if len(p.Stashes) > 0 {
+		risks = append(risks, fmt.Sprintf("%s made on this branch", plural(len(p.Stashes), "stash")))
+	}
+	return risks
+}
+
+// PreflightRemoval checks a worktree for uncommitted changes, untracked
+// files, unpushed commits and stashes before it is removed. With
+// checkBranch, it also finds out whether the branch can be deleted and
+// which remote branch it tracks; otherwise BranchMerged and Remote are
+// left empty.
+func (r *Repository) PreflightRemoval(ctx context.Context, wt Worktree, checkBranch bool) (RemovalPreflight, error) {
+	preflight := RemovalPreflight{Worktree: wt}
+
+	if checkBranch && wt.Branch != "" {
+		merged, err := r.IsBranchMerged(ctx, wt.Branch)
+		if err != nil {
+			return preflight, err
+		}
+		preflight.BranchMerged = merged
+
+		remote, remoteBranch, err := r.BranchRemote(ctx, wt.Branch)
+		if err != nil {
+			return preflight, err
+		}
+		preflight.Remote = remote
+		preflight.RemoteBranch = remoteBranch
+	}
+
+	// A prunable worktree's directory is already gone, so there is nothing
+	// left in it to inspect
+	if wt.Prunable {
+		return preflight, nil
+	}
+
+	status, err := r.WorktreeStatus(ctx, wt.Path)
+	if err != nil {
+		return preflight, err
+	}
+	preflight.Status = status
+
+	unpushed, err := r.countUnpushed(ctx, wt)
+	if err != nil {
+		return preflight, err
+	}
+	preflight.Unpushed = unpushed
+
+	if wt.Branch != "" {
+		stashes, err := r.branchStashes(ctx, wt.Branch)
+		if err != nil {
+			return preflight, err
+		}
+		preflight.Stashes = stashes
+	}
+
+	return preflight, nil
+}
+
+// countUnpushed counts commits that exist only on the worktree's branch or
+// detached HEAD. Commits reachable from a remote or another local branch,
+// such as the history a new branch was created from, are not at risk.
+func (r *Repository) countUnpushed(ctx context.Context, wt Worktree) (int, error) {
+	args := []string{"-C", wt.Path, "rev-list", "--count"}
+	if wt.Branch != "" {
+		// --exclude patterns for --branches leave out the refs/heads/ prefix
+		args = append(args, "refs/heads/"+wt.Branch, "--not", "--exclude="+wt.Branch, "--branches", "--remotes")
+	} else {
+		args = append(args, "HEAD", "--not", "--branches", "--remotes")
+	}
+
+	out, err := r.runner.Run(ctx, args...)
+	if err != nil {
+		if ctx.Err() != nil {
+			return 0, fmt.Errorf("failed to count unpushed commits: %w", err)
+		}
+		// Unborn branch: nothing committed, nothing to lose
+		return 0, nil
+	}
+
+	count, err := strconv.Atoi(strings.TrimSpace(out))
+	if err != nil {
+		return 0, fmt.Errorf("failed to count unpushed commits: %w", err)
+	}
+	return count, nil
+}
+
+// branchStashes returns the stash entries recorded while branch was checked out
+func (r *Repository) branchStashes(ctx context.Context, branch string) ([]string, error) {
+	out, err := r.runner.Run(ctx, "stash", "list", "--format=%gd: %gs")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stashes: %w", err)
+	}
+	return filterStashes(out, branch), nil
+}
+
+// filterStashes keeps the 'git stash list' lines whose message names branch
+// ("WIP on <branch>: ..." or "On <branch>: ...")
+func filterStashes(output, branch string) []string {
+	var stashes []string
+	for _, line := range strings.Split(output, "\n") {
+		if line == "" {
+			continue
+		}
+		if strings.Contains(line, ": WIP on "+branch+": ") || strings.Contains(line, ": On "+branch+": ") {
+			stashes = append(stashes, line)
+		}
+	}
+	return stashes
+}
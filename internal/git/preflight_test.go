@@ -0,0 +1,67 @@
+package git
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCountUnpushed(t *testing.T) {
+	tests := []struct {
+		name string
+		wt   Worktree
+		cmd  string
+	}{
+		{
+			name: "branch",
+			wt:   Worktree{Path: "/src/wt/x", Branch: "feature/x"},
+			// History shared with other branches, e.g. the base, is not at risk
+			cmd: "-C /src/wt/x rev-list --count refs/heads/feature/x --not --exclude=feature/x --branches --remotes",
+		},
+		{
+			name: "detached",
+			wt:   Worktree{Path: "/src/wt/x", IsDetached: true},
+			cmd:  "-C /src/wt/x rev-list --count HEAD --not --branches --remotes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, _ := newFakeRepository(map[string]fakeResult{tt.cmd: {out: "3\n"}})
+			got, err := repo.countUnpushed(context.Background(), tt.wt)
+			if err != nil {
+				t.Fatalf("countUnpushed() error = %v", err)
+			}
+			if got != 3 {
+				t.Errorf("countUnpushed() = %d, want 3", got)
+			}
+		})
+	}
+}
+
+func TestPreflightRemovalBranch(t *testing.T) {
+	// A prunable worktree has no directory to inspect, leaving the branch checks
+	wt := Worktree{Path: "/src/wt/x", Branch: "feature/x", Prunable: true}
+
+	repo, runner := newFakeRepository(nil)
+	if _, err := repo.PreflightRemoval(context.Background(), wt, false); err != nil {
+		t.Fatalf("PreflightRemoval() error = %v", err)
+	}
+	if len(runner.calls) != 0 {
+		t.Errorf("PreflightRemoval() without checkBranch ran %q", runner.calls)
+	}
+
+	// The upstream was deleted after merging and pruned by a fetch
+	repo, _ = newFakeRepository(map[string]fakeResult{
+		"for-each-ref --format=%(upstream) refs/heads/feature/x":                                                        {out: "refs/remotes/origin/feature/x\n"},
+		"rev-parse --verify --quiet refs/remotes/origin/feature/x^{commit}":                                             {err: exitError(t, 1, "rev-parse")},
+		"merge-base --is-ancestor refs/heads/feature/x HEAD":                                                            {},
+		"for-each-ref --format=%(upstream:remotename)%00%(upstream:remoteref)%00%(upstream:track) refs/heads/feature/x": {out: "origin\x00refs/heads/feature/x\x00[gone]\n"},
+	})
+	preflight, err := repo.PreflightRemoval(context.Background(), wt, true)
+	if err != nil {
+		t.Fatalf("PreflightRemoval() error = %v", err)
+	}
+	if !preflight.BranchMerged || preflight.Remote != "" {
+		t.Errorf("PreflightRemoval() = merged %v, remote %q; want merged into HEAD and no remote", preflight.BranchMerged, preflight.Remote)
+	}
+}
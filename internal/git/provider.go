@@ -0,0 +1,23 @@
+package git
+
+import "context"
+
+// BranchNameProvider suggests complete branch names from outside the
+// repository, such as the issues assigned to the user in a tracker
+type BranchNameProvider interface {
+	// Name describes where the names come from, e.g. "GitHub issues"
+	Name() string
+	// SuggestBranchNames returns branch names, each starting with prefix
+	SuggestBranchNames(ctx context.Context, prefix string) ([]BranchNameSuggestion, error)
+}
+
+// AddBranchNameProvider adds a source of branch names to offer next to
+// the learned prefixes
+func (r *Repository) AddBranchNameProvider(p BranchNameProvider) {
+	r.providers = append(r.providers, p)
+}
+
+// BranchNameProviders returns the added sources of branch names
+func (r *Repository) BranchNameProviders() []BranchNameProvider {
+	return r.providers
+}
@@ -0,0 +1,119 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidateBranchName checks name against the rules git applies to new
+// branch names (`git check-ref-format --branch`), without running git so
+// it can be used while the name is typed
+func ValidateBranchName(name string) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("branch name cannot be empty")
+	case name == "@":
+		return fmt.Errorf("branch name cannot be '@'")
+	case name == "HEAD":
+		return fmt.Errorf("branch name cannot be 'HEAD'")
+	case strings.HasPrefix(name, "-"):
+		return fmt.Errorf("branch name cannot start with '-'")
+	case strings.HasPrefix(name, "/") || strings.HasSuffix(name, "/"):
+		return fmt.Errorf("branch name cannot start or end with '/'")
+	case strings.HasSuffix(name, "."):
+		return fmt.Errorf("branch name cannot end with '.'")
+	case strings.Contains(name, "//"):
+		return fmt.Errorf("branch name cannot contain '//'")
+	case strings.Contains(name, ".."):
+		return fmt.Errorf("branch name cannot contain '..'")
+	case strings.Contains(name, "@{"):
+		return fmt.Errorf("branch name cannot contain '@{'")
+	}
+
+	for _, c := range name {
+		if c == ' ' {
+			return fmt.Errorf("branch name cannot contain spaces")
+		}
+		if c < 0x20 || c == 0x7f {
+			return fmt.Errorf("branch name cannot contain control characters")
+		}
+		if strings.ContainsRune(`~^:?*[\`, c) {
+			return fmt.Errorf("branch name cannot contain '%c'", c)
+		}
+	}
+
+	for _, component := range strings.Split(name, "/") {
+		if strings.HasPrefix(component, ".") {
+			return fmt.Errorf("'%s': a part of a branch name cannot start with '.'", component)
+		}
+		if strings.HasSuffix(component, ".lock") {
+			return fmt.Errorf("'%s': a part of a branch name cannot end with '.lock'", component)
+		}
+	}
+	return nil
+}
+
+// CheckNewBranchName validates name and checks that it can be created next
+// to the existing local branches: it must not exist yet, and since branches
+// are stored as files it can neither be inside nor contain another branch
+func CheckNewBranchName(name string, branches []Branch) error {
+	if err := ValidateBranchName(name); err != nil {
+		return err
+	}
+	for _, b := range branches {
+		if !b.IsLocal {
+			continue
+		}
+		switch {
+		case b.Name == name:
+			return fmt.Errorf("branch '%s' already exists", name)
+		case strings.HasPrefix(name, b.Name+"/"):
+			return fmt.Errorf("branch '%s' exists, so '%s' cannot be created", b.Name, name)
+		case strings.HasPrefix(b.Name, name+"/"):
+			return fmt.Errorf("branch '%s' exists, so '%s' cannot be created", b.Name, name)
+		}
+	}
+	return nil
+}
+
+// SanitizeBranchName turns name into a valid branch name, replacing
+// spaces and forbidden characters with '-', e.g. "Fix login: 2FA?" becomes
+// "Fix-login-2FA". It returns "" if nothing usable is left.
+func SanitizeBranchName(name string) string {
+	var b strings.Builder
+	for _, c := range strings.TrimSpace(name) {
+		if c <= ' ' || c == 0x7f || strings.ContainsRune(`~^:?*[\`, c) {
+			b.WriteRune('-')
+			continue
+		}
+		b.WriteRune(c)
+	}
+	s := strings.ReplaceAll(b.String(), "@{", "-")
+
+	var components []string
+	for _, component := range strings.Split(s, "/") {
+		for strings.Contains(component, "..") {
+			component = strings.ReplaceAll(component, "..", ".")
+		}
+		for strings.Contains(component, "--") {
+			component = strings.ReplaceAll(component, "--", "-")
+		}
+		for {
+			trimmed := strings.TrimSuffix(component, ".lock")
+			trimmed = strings.Trim(trimmed, ".-")
+			if trimmed == component {
+				break
+			}
+			component = trimmed
+		}
+		if component != "" {
+			components = append(components, component)
+		}
+	}
+
+	s = strings.Join(components, "/")
+	if s == "@" || s == "HEAD" {
+		return ""
+	}
+	return s
+}
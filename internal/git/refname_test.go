@@ -0,0 +1,163 @@
+package git
+
+import (
+	"os/exec"
+	"testing"
+)
+
+// branchNameCases are names with whether git accepts them as new branch
+// names
+var branchNameCases = []struct {
+	name  string
+	valid bool
+}{
+	{"feature/auth", true},
+	{"fix/PROJ-123-login", true},
+	{"a/-x", true},
+	{"a-", true},
+	{"a@b", true},
+	{"@/x", true},
+	{"x/@", true},
+	{"HEAD/x", true},
+	{"é/ü", true},
+	{"", false},
+	{"HEAD", false},
+	{"-x", false},
+	{"-", false},
+	{"x.", false},
+	{".", false},
+	{"a.lock", false},
+	{"a.lock/b", false},
+	{"a/b.lock/c", false},
+	{"a/.b", false},
+	{".hidden", false},
+	{"a b", false},
+	{"a\tb", false},
+	{"a\x7fb", false},
+	{`a\b`, false},
+	{"a~b", false},
+	{"a^b", false},
+	{"a:b", false},
+	{"a?b", false},
+	{"a*b", false},
+	{"a[b", false},
+	{"a@{b", false},
+	{"a..b", false},
+	{"a//b", false},
+	{"/a", false},
+	{"a/", false},
+}
+
+// gitAcceptsBranchName asks git whether name is a valid new branch name
+func gitAcceptsBranchName(t *testing.T, name string) bool {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	// Outside a repository, so "@" is not expanded to the current branch
+	cmd := exec.Command("git", "check-ref-format", "--branch", name)
+	cmd.Dir = t.TempDir()
+	return cmd.Run() == nil
+}
+
+func TestValidateBranchName(t *testing.T) {
+	for _, tt := range branchNameCases {
+		err := ValidateBranchName(tt.name)
+		if (err == nil) != tt.valid {
+			t.Errorf("ValidateBranchName(%q) = %v, want valid %v", tt.name, err, tt.valid)
+		}
+		if tt.name != "" && gitAcceptsBranchName(t, tt.name) != tt.valid {
+			t.Errorf("git check-ref-format --branch %q disagrees with valid %v", tt.name, tt.valid)
+		}
+	}
+}
+
+func TestValidateBranchNameRejectsAt(t *testing.T) {
+	// git would take "@" for HEAD; 'git branch @' refuses it as well
+	if err := ValidateBranchName("@"); err == nil {
+		t.Error("ValidateBranchName(\"@\") = nil, want an error")
+	}
+}
+
+func TestSanitizeBranchName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"feature/auth", "feature/auth"},
+		{"Fix login: 2FA?", "Fix-login-2FA"},
+		{"  spaced  out  ", "spaced-out"},
+		{"a..b", "a.b"},
+		{"a//b", "a/b"},
+		{"/a/", "a"},
+		{"-x", "x"},
+		{"x.", "x"},
+		{"x.lock", "x"},
+		{"x.lock.lock", "x"},
+		{"a/.hidden/b", "a/hidden/b"},
+		{"a@{1}", "a-1}"},
+		{"a~1^2", "a-1-2"},
+		{"tab\there", "tab-here"},
+		{"日本語/ブランチ", "日本語/ブランチ"},
+		{"@", ""},
+		{"HEAD", ""},
+		{"...", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := SanitizeBranchName(tt.name); got != tt.want {
+			t.Errorf("SanitizeBranchName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSanitizeBranchNameIsValid(t *testing.T) {
+	names := []string{"Fix login: 2FA?", "a@{b", "a..lock", "x/.lock", "--a--", "a/-/b", "a.lock/.b./c..", " [WIP] *new* ", "a\x7f\x01b"}
+	for _, tt := range branchNameCases {
+		names = append(names, tt.name)
+	}
+
+	for _, name := range names {
+		sanitized := SanitizeBranchName(name)
+		if sanitized == "" {
+			continue
+		}
+		if err := ValidateBranchName(sanitized); err != nil {
+			t.Errorf("SanitizeBranchName(%q) = %q, which is invalid: %v", name, sanitized, err)
+		}
+		if !gitAcceptsBranchName(t, sanitized) {
+			t.Errorf("SanitizeBranchName(%q) = %q, which git rejects", name, sanitized)
+		}
+	}
+}
+
+func TestCheckNewBranchName(t *testing.T) {
+	branches := []Branch{
+		{Name: "main", IsLocal: true},
+		{Name: "feature/auth", IsLocal: true},
+		{Name: "team", IsLocal: true},
+		{Name: "fix/remote-only", Remote: "origin"},
+	}
+	tests := []struct {
+		name string
+		want string // Error; empty if the name can be created
+	}{
+		{"feature/login", ""},
+		{"fix/remote-only", ""}, // A local branch may shadow a remote one
+		{"main", "branch 'main' already exists"},
+		{"feature", "branch 'feature/auth' exists, so 'feature' cannot be created"},
+		{"team/x", "branch 'team' exists, so 'team/x' cannot be created"},
+		{"feature/auth/v2", "branch 'feature/auth' exists, so 'feature/auth/v2' cannot be created"},
+		{"bad name", "branch name cannot contain spaces"},
+	}
+	for _, tt := range tests {
+		err := CheckNewBranchName(tt.name, branches)
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != tt.want {
+			t.Errorf("CheckNewBranchName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
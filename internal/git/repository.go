@@ -0,0 +1,94 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/FScoward/rakutree/internal/config"
+	"github.com/FScoward/rakutree/internal/history"
+)
+
+// Repository is a git repository that all worktree operations go through
+type Repository struct {
+	dir       string
+	runner    Runner
+	config    config.Config
+	history   *history.History
+	providers []BranchNameProvider
+}
+
+// NewRepository returns a repository rooted at dir that runs the git binary.
+// An empty dir means the process working directory.
+func NewRepository(dir string) *Repository {
+	return NewRepositoryWithRunner(dir, &ExecRunner{Dir: dir})
+}
+
+// NewRepositoryWithRunner returns a repository at dir whose git commands
+// are executed by runner
+func NewRepositoryWithRunner(dir string, runner Runner) *Repository {
+	return &Repository{dir: dir, runner: runner}
+}
+
+// Dir returns the directory the repository was opened at
+func (r *Repository) Dir() string {
+	return r.dir
+}
+
+// AbsPath resolves path, which may be relative to the repository directory
+// (as git interprets it), to an absolute path
+func (r *Repository) AbsPath(path string) (string, error) {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path), nil
+	}
+	return filepath.Abs(filepath.Join(r.dir, path))
+}
+
+// Config returns the configuration the suggestions are tuned with
+func (r *Repository) Config() config.Config {
+	return r.config
+}
+
+// SetConfig sets the configuration the suggestions are tuned with
+func (r *Repository) SetConfig(cfg config.Config) {
+	r.config = cfg
+}
+
+// Root locates a repository on disk
+type Root struct {
+	TopLevel  string // Root of the worktree the repository was opened in; empty in a bare repository
+	CommonDir string // Git directory shared by all worktrees
+	Main      string // Root of the main worktree (the repository itself if bare)
+}
+
+// Root resolves where the repository lives, independent of the
+// subdirectory or linked worktree it was opened in
+func (r *Repository) Root(ctx context.Context) (Root, error) {
+	out, err := r.runner.Run(ctx, "rev-parse", "--git-common-dir")
+	if err != nil {
+		return Root{}, fmt.Errorf("not in a git repository: %w", err)
+	}
+	// Older git prints the common dir relative to the working directory
+	commonDir, err := r.AbsPath(strings.TrimSpace(out))
+	if err != nil {
+		return Root{}, err
+	}
+	root := Root{CommonDir: commonDir}
+
+	if out, err := r.runner.Run(ctx, "rev-parse", "--show-toplevel"); err == nil {
+		root.TopLevel = strings.TrimSpace(out)
+	}
+
+	if filepath.Base(commonDir) == ".git" {
+		root.Main = filepath.Dir(commonDir)
+	} else {
+		// Bare repository or separate git dir: ask git where the main worktree is
+		main, err := r.MainWorktree(ctx)
+		if err != nil {
+			return Root{}, err
+		}
+		root.Main = main.Path
+	}
+	return root, nil
+}
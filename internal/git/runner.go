@@ -0,0 +1,98 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Runner executes git commands on behalf of a Repository. Implementations
+// other than ExecRunner can be injected for tests or alternative backends.
+type Runner interface {
+	// Run executes git with args and returns its standard output
+	Run(ctx context.Context, args ...string) (string, error)
+}
+
+// ExecRunner runs the git binary as a child process
+type ExecRunner struct {
+	Dir     string        // Working directory; empty means the process cwd
+	GitPath string        // Path to the git binary; empty means "git" from PATH
+	Env     []string      // Extra environment variables in "KEY=value" form
+	Timeout time.Duration // Per-command time limit; zero means no limit
+}
+
+// Run executes git with args and returns its standard output
+func (r *ExecRunner) Run(ctx context.Context, args ...string) (string, error) {
+	gitPath := r.GitPath
+	if gitPath == "" {
+		gitPath = "git"
+	}
+
+	if r.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.Timeout)
+		defer cancel()
+	}
+
+	// The process is killed when ctx is done
+	cmd := exec.CommandContext(ctx, gitPath, args...)
+	cmd.Dir = r.Dir
+	if len(r.Env) > 0 {
+		cmd.Env = append(os.Environ(), r.Env...)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return stdout.String(), &CommandError{Args: args, Err: ctxErr}
+		}
+		return stdout.String(), &CommandError{
+			Args:   args,
+			Stderr: strings.TrimSpace(stderr.String()),
+			Err:    err,
+		}
+	}
+	return stdout.String(), nil
+}
+
+// CommandError is returned when a git command exits unsuccessfully
+type CommandError struct {
+	Args   []string
+	Stderr string
+	Err    error
+}
+
+func (e *CommandError) Error() string {
+	switch {
+	case errors.Is(e.Err, context.Canceled):
+		return fmt.Sprintf("git %s was cancelled", e.subcommand())
+	case errors.Is(e.Err, context.DeadlineExceeded):
+		return fmt.Sprintf("git %s timed out", e.subcommand())
+	case e.Stderr != "":
+		return e.Stderr
+	}
+	return e.Err.Error()
+}
+
+// subcommand returns the leading non-flag arguments, e.g. "worktree add"
+func (e *CommandError) subcommand() string {
+	var words []string
+	for _, arg := range e.Args {
+		if strings.HasPrefix(arg, "-") || len(words) == 2 {
+			break
+		}
+		words = append(words, arg)
+	}
+	return strings.Join(words, " ")
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,52 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeResult is what fakeRunner returns for one git command
+type fakeResult struct {
+	out string
+	err error
+}
+
+// fakeRunner answers git commands from a table and records the commands
+// it was asked to run. Commands not in the table fail.
+type fakeRunner struct {
+	results map[string]fakeResult // Keyed by the arguments joined with spaces
+	calls   []string
+}
+
+func (f *fakeRunner) Run(ctx context.Context, args ...string) (string, error) {
+	cmd := strings.Join(args, " ")
+	f.calls = append(f.calls, cmd)
+	result, ok := f.results[cmd]
+	if !ok {
+		return "", &CommandError{Args: args, Stderr: "unexpected command: git " + cmd, Err: errors.New("unexpected command")}
+	}
+	return result.out, result.err
+}
+
+// newFakeRepository returns a repository whose git commands are answered
+// by results
+func newFakeRepository(results map[string]fakeResult) (*Repository, *fakeRunner) {
+	runner := &fakeRunner{results: results}
+	return NewRepositoryWithRunner("/repo", runner), runner
+}
+
+// exitError returns the error of a git command that exited with code, as
+// ExecRunner reports it
+func exitError(t *testing.T, code int, args ...string) error {
+	t.Helper()
+	err := exec.Command("sh", "-c", "exit "+strconv.Itoa(code)).Run()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("failed to produce exit code %d: %v", code, err)
+	}
+	return &CommandError{Args: args, Err: exitErr}
+}
@@ -0,0 +1,179 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// statusConcurrency limits how many worktrees are inspected in parallel
+const statusConcurrency = 8
+
+// WorktreeStatus summarizes the state of a worktree's working copy
+type WorktreeStatus struct {
+	Staged     int `json:"staged"`
+	Unstaged   int `json:"unstaged"`
+	Untracked  int `json:"untracked"`
+	Conflicted int `json:"conflicted"`
+
+	Upstream string `json:"upstream,omitempty"` // Empty when no upstream is configured
+	Ahead    int    `json:"ahead"`
+	Behind   int    `json:"behind"`
+
+	LastCommitSubject string    `json:"last_commit_subject,omitempty"`
+	LastCommitAuthor  string    `json:"last_commit_author,omitempty"`
+	LastCommitTime    time.Time `json:"last_commit_time,omitzero"`
+}
+
+// IsDirty reports whether the worktree has any uncommitted changes
+func (s WorktreeStatus) IsDirty() bool {
+	return s.Staged+s.Unstaged+s.Untracked+s.Conflicted > 0
+}
+
+// WorktreeStatus inspects the worktree at path: uncommitted changes,
+// ahead/behind relative to the upstream and the last commit
+func (r *Repository) WorktreeStatus(ctx context.Context, path string) (WorktreeStatus, error) {
+	out, err := r.runner.Run(ctx, "-C", path, "status", "--porcelain=v2", "--branch")
+	if err != nil {
+		return WorktreeStatus{}, fmt.Errorf("failed to get status of %s: %w", path, err)
+	}
+	status := parseStatus(out)
+
+	// A worktree on an unborn branch has no commits yet
+	out, err = r.runner.Run(ctx, "-C", path, "log", "-1", "--format=%s%x00%an%x00%ct")
+	if err == nil {
+		parseLastCommit(&status, out)
+	} else if ctx.Err() != nil {
+		return WorktreeStatus{}, fmt.Errorf("failed to get last commit of %s: %w", path, err)
+	}
+
+	return status, nil
+}
+
+// WorktreeStatuses inspects all worktrees in parallel. Worktrees whose
+// status cannot be read (e.g. prunable ones) are reported in errs.
+func (r *Repository) WorktreeStatuses(ctx context.Context, worktrees []Worktree) (statuses map[string]WorktreeStatus, errs map[string]error) {
+	statuses = make(map[string]WorktreeStatus)
+	errs = make(map[string]error)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, statusConcurrency)
+
+	for _, wt := range worktrees {
+		if wt.IsBare || wt.Prunable {
+			continue
+		}
+
+		wg.Add(1)
+		go func(path string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			status, err := r.WorktreeStatus(ctx, path)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[path] = err
+			} else {
+				statuses[path] = status
+			}
+		}(wt.Path)
+	}
+	wg.Wait()
+
+	return statuses, errs
+}
+
+// parseStatus parses the output of 'git status --porcelain=v2 --branch'
+func parseStatus(output string) WorktreeStatus {
+	var status WorktreeStatus
+
+	for _, line := range strings.Split(output, "\n") {
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		switch fields[0] {
+		case "#":
+			if len(fields) < 3 {
+				continue
+			}
+			switch fields[1] {
+			case "branch.upstream":
+				status.Upstream = fields[2]
+			case "branch.ab":
+				if len(fields) == 4 {
+					status.Ahead, _ = strconv.Atoi(strings.TrimPrefix(fields[2], "+"))
+					status.Behind, _ = strconv.Atoi(strings.TrimPrefix(fields[3], "-"))
+				}
+			}
+		case "1", "2":
+			// Ordinary or renamed entry: XY holds staged and unstaged state
+			if len(fields) < 2 || len(fields[1]) != 2 {
+				continue
+			}
+			if fields[1][0] != '.' {
+				status.Staged++
+			}
+			if fields[1][1] != '.' {
+				status.Unstaged++
+			}
+		case "u":
+			status.Conflicted++
+		case "?":
+			status.Untracked++
+		}
+	}
+
+	return status
+}
+
+// parseLastCommit fills in the last commit from 'git log -1 --format=%s%x00%an%x00%ct'
+func parseLastCommit(status *WorktreeStatus, output string) {
+	parts := strings.SplitN(strings.TrimRight(output, "\n"), "\x00", 3)
+	if len(parts) != 3 {
+		return
+	}
+
+	status.LastCommitSubject = parts[0]
+	status.LastCommitAuthor = parts[1]
+	if ts, err := strconv.ParseInt(parts[2], 10, 64); err == nil {
+		status.LastCommitTime = time.Unix(ts, 0)
+	}
+}
+
+// RelativeAge formats the time elapsed since t, e.g. "3 days ago"
+func RelativeAge(t time.Time, now time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return plural(int(d/time.Minute), "minute") + " ago"
+	case d < 24*time.Hour:
+		return plural(int(d/time.Hour), "hour") + " ago"
+	case d < 30*24*time.Hour:
+		return plural(int(d/(24*time.Hour)), "day") + " ago"
+	case d < 365*24*time.Hour:
+		return plural(int(d/(30*24*time.Hour)), "month") + " ago"
+	}
+	return plural(int(d/(365*24*time.Hour)), "year") + " ago"
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
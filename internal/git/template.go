@@ -0,0 +1,152 @@
+package git
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/user"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultShortLength is the length the short filter truncates to
+const defaultShortLength = 32
+
+// TemplateVars are the values a path template refers to. Placeholders are
+// written {name} or {name|filter|...}:
+//
+//	{branch}  the branch name, e.g. "feature/auth"
+//	{prefix}  the part before the last '/', e.g. "feature"; empty if none
+//	{leaf}    the part after the last '/', e.g. "auth"
+//	{repo}    the repository name
+//	{user}    the login name of the current user
+//	{date}    today's date, e.g. "2024-05-01"
+//
+// Filters pick how a branch becomes a directory name:
+//
+//	slug      replace '/' and characters unsafe in paths with '-'
+//	lower     lowercase
+//	short[:N] truncate to N characters (default 32), ending in a hash of the full value
+type TemplateVars struct {
+	Branch string
+	Repo   string
+	User   string
+	Date   time.Time
+}
+
+var placeholderRe = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// ExpandTemplate replaces the placeholders of template with vars
+func ExpandTemplate(template string, vars TemplateVars) (string, error) {
+	var firstErr error
+	expanded := placeholderRe.ReplaceAllStringFunc(template, func(placeholder string) string {
+		value, err := vars.expand(placeholder[1 : len(placeholder)-1])
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("invalid path template '%s': %w", template, err)
+		}
+		return value
+	})
+	if firstErr != nil {
+		return "", firstErr
+	}
+	return expanded, nil
+}
+
+// expand evaluates the inside of one placeholder, e.g. "branch|slug"
+func (v TemplateVars) expand(placeholder string) (string, error) {
+	parts := strings.Split(placeholder, "|")
+
+	var value string
+	switch name := strings.TrimSpace(parts[0]); name {
+	case "branch":
+		value = v.Branch
+	case "prefix":
+		if i := strings.LastIndex(v.Branch, "/"); i >= 0 {
+			value = v.Branch[:i]
+		}
+	case "leaf":
+		value = v.Branch[strings.LastIndex(v.Branch, "/")+1:]
+	case "repo":
+		value = v.Repo
+	case "user":
+		value = v.User
+	case "date":
+		value = v.Date.Format("2006-01-02")
+	default:
+		return "", fmt.Errorf("unknown placeholder '{%s}'", name)
+	}
+
+	for _, filter := range parts[1:] {
+		var err error
+		if value, err = applyFilter(strings.TrimSpace(filter), value); err != nil {
+			return "", err
+		}
+	}
+	return value, nil
+}
+
+func applyFilter(filter, value string) (string, error) {
+	name, arg, hasArg := strings.Cut(filter, ":")
+	switch {
+	case name == "slug" && !hasArg:
+		return slugify(value), nil
+	case name == "lower" && !hasArg:
+		return strings.ToLower(value), nil
+	case name == "short":
+		length := defaultShortLength
+		if hasArg {
+			n, err := strconv.Atoi(arg)
+			if err != nil || n < 8 {
+				return "", fmt.Errorf("short needs a length of at least 8, got '%s'", arg)
+			}
+			length = n
+		}
+		return shorten(value, length), nil
+	}
+	return "", fmt.Errorf("unknown filter '%s'", filter)
+}
+
+var unsafePathRe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// slugify flattens value into a single directory name, e.g.
+// "feature/auth" becomes "feature-auth"
+func slugify(value string) string {
+	return strings.Trim(unsafePathRe.ReplaceAllString(value, "-"), "-")
+}
+
+// shorten truncates value to length characters, replacing the end with a
+// hash of the whole value so truncated names stay distinct
+func shorten(value string, length int) string {
+	runes := []rune(value)
+	if len(runes) <= length {
+		return value
+	}
+	sum := sha1.Sum([]byte(value))
+	hash := hex.EncodeToString(sum[:])[:6]
+	// Cut whole characters so multi-byte names stay valid UTF-8
+	return strings.TrimRight(string(runes[:length-len(hash)-1]), "-/.") + "-" + hash
+}
+
+// currentUser returns the login name used for {user}
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// branchStrategies are the ways a worktree directory can be derived from
+// its branch, most specific last so ties go to the simplest
+var branchStrategies = []string{
+	"{branch}",
+	"{branch|slug}",
+	"{branch|lower}",
+	"{branch|slug|lower}",
+	"{leaf}",
+	"{leaf|lower}",
+	"{branch|slug|short}",
+	"{branch|slug|lower|short}",
+}
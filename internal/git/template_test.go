@@ -0,0 +1,120 @@
+package git
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestExpandTemplate(t *testing.T) {
+	vars := TemplateVars{
+		Branch: "feature/Auth",
+		Repo:   "app",
+		User:   "alice",
+		Date:   time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{"/wt/{branch}", "/wt/feature/Auth"},
+		{"/wt/{branch|slug}", "/wt/feature-Auth"},
+		{"/wt/{branch|lower}", "/wt/feature/auth"},
+		{"/wt/{branch|slug|lower}", "/wt/feature-auth"},
+		{"/wt/{ branch | slug }", "/wt/feature-Auth"},
+		{"/wt/{prefix}/{leaf}", "/wt/feature/Auth"},
+		{"/wt/{repo}-{leaf|lower}", "/wt/app-auth"},
+		{"/wt/{user}/{date}/{branch|slug}", "/wt/alice/2024-05-01/feature-Auth"},
+		{"/wt/{branch|short}", "/wt/feature/Auth"},
+		{"/wt/{branch|slug|short:8}", "/wt/f-" + hashOf("feature-Auth")},
+		{"/wt/fixed", "/wt/fixed"},
+	}
+	for _, tt := range tests {
+		got, err := ExpandTemplate(tt.template, vars)
+		if err != nil {
+			t.Errorf("ExpandTemplate(%q) error = %v", tt.template, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ExpandTemplate(%q) = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestExpandTemplateNoPrefix(t *testing.T) {
+	got, err := ExpandTemplate("/wt/{prefix}/{leaf}", TemplateVars{Branch: "main"})
+	if err != nil || got != "/wt//main" {
+		t.Errorf("ExpandTemplate() = %q, %v; want %q", got, err, "/wt//main")
+	}
+}
+
+func TestExpandTemplateErrors(t *testing.T) {
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{"/wt/{branch", ""}, // Not a placeholder, kept as is
+		{"/wt/{name}", "invalid path template '/wt/{name}': unknown placeholder '{name}'"},
+		{"/wt/{branch|upper}", "invalid path template '/wt/{branch|upper}': unknown filter 'upper'"},
+		{"/wt/{branch|slug:2}", "invalid path template '/wt/{branch|slug:2}': unknown filter 'slug:2'"},
+		{"/wt/{branch|short:4}", "invalid path template '/wt/{branch|short:4}': short needs a length of at least 8, got '4'"},
+		{"/wt/{branch|short:x}", "invalid path template '/wt/{branch|short:x}': short needs a length of at least 8, got 'x'"},
+	}
+	for _, tt := range tests {
+		_, err := ExpandTemplate(tt.template, TemplateVars{Branch: "feature/auth"})
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != tt.want {
+			t.Errorf("ExpandTemplate(%q) error = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestShorten(t *testing.T) {
+	if got := shorten("feature-auth", 32); got != "feature-auth" {
+		t.Errorf("shorten() of a short value = %q, want it unchanged", got)
+	}
+
+	long := "feature-a-very-long-branch-name-for-something"
+	got := shorten(long, 20)
+	if want := "feature-a-ver-" + hashOf(long); got != want {
+		t.Errorf("shorten(%q, 20) = %q, want %q", long, got, want)
+	}
+	if other := shorten(long+"-else", 20); other == got {
+		t.Errorf("shorten() gave %q for two different values", got)
+	}
+
+	// Separators before the hash are dropped
+	if got := shorten("abcdefg/xyz-long-tail", 14); got != "abcdefg-"+hashOf("abcdefg/xyz-long-tail") {
+		t.Errorf("shorten() = %q", got)
+	}
+}
+
+func TestShortenMultiByte(t *testing.T) {
+	for _, value := range []string{"日本語のとても長いブランチ名です", "fix/ログイン画面の不具合を修正する", "émoji-🎉-🎉-🎉-🎉-🎉"} {
+		for length := 8; length <= 16; length++ {
+			got := shorten(value, length)
+			if !utf8.ValidString(got) {
+				t.Errorf("shorten(%q, %d) = %q, which is not valid UTF-8", value, length, got)
+			}
+			if n := utf8.RuneCountInString(got); n > length {
+				t.Errorf("shorten(%q, %d) = %q, %d characters long", value, length, got, n)
+			}
+		}
+	}
+
+	got, err := ExpandTemplate("{leaf|short:8}", TemplateVars{Branch: "feature/日本語のブランチ名"})
+	if err != nil || got != "日-"+hashOf("日本語のブランチ名") {
+		t.Errorf("ExpandTemplate() = %q, %v", got, err)
+	}
+}
+
+// hashOf is the hash shorten ends a truncated value in
+func hashOf(value string) string {
+	sum := sha1.Sum([]byte(value))
+	return hex.EncodeToString(sum[:])[:6]
+}
@@ -1,31 +1,77 @@
 package git
 
 import (
-	"bytes"
+	"context"
 	"fmt"
-	"os"
-	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
+
+	"github.com/FScoward/rakutree/internal/config"
 )
 
 // Worktree represents a git worktree
 type Worktree struct {
-	Path   string
-	Branch string
-	Commit string
+	Path           string `json:"path"`
+	Branch         string `json:"branch"`
+	Commit         string `json:"commit"`
+	IsBare         bool   `json:"bare"`
+	IsDetached     bool   `json:"detached"`
+	Locked         bool   `json:"locked"`
+	LockReason     string `json:"lock_reason,omitempty"`
+	Prunable       bool   `json:"prunable"`
+	PrunableReason string `json:"prunable_reason,omitempty"`
+}
+
+// Annotations returns short human-readable labels for the worktree's
+// special states (bare, detached, locked, prunable)
+func (w Worktree) Annotations() []string {
+	var labels []string
+	if w.IsBare {
+		labels = append(labels, "bare")
+	}
+	if w.IsDetached {
+		labels = append(labels, "detached")
+	}
+	if w.Locked {
+		if w.LockReason != "" {
+			labels = append(labels, "locked: "+w.LockReason)
+		} else {
+			labels = append(labels, "locked")
+		}
+	}
+	if w.Prunable {
+		if w.PrunableReason != "" {
+			labels = append(labels, "prunable: "+w.PrunableReason)
+		} else {
+			labels = append(labels, "prunable")
+		}
+	}
+	return labels
 }
 
 // ListWorktrees returns a list of all worktrees
-func ListWorktrees() ([]Worktree, error) {
-	cmd := exec.Command("git", "worktree", "list", "--porcelain")
-	var out bytes.Buffer
-	cmd.Stdout = &out
-	if err := cmd.Run(); err != nil {
+func (r *Repository) ListWorktrees(ctx context.Context) ([]Worktree, error) {
+	out, err := r.runner.Run(ctx, "worktree", "list", "--porcelain")
+	if err != nil {
 		return nil, fmt.Errorf("failed to list worktrees: %w", err)
 	}
 
-	return parseWorktrees(out.String()), nil
+	return parseWorktrees(out), nil
+}
+
+// MainWorktree returns the main worktree, the one the repository was
+// cloned or initialized in
+func (r *Repository) MainWorktree(ctx context.Context) (Worktree, error) {
+	worktrees, err := r.ListWorktrees(ctx)
+	if err != nil {
+		return Worktree{}, err
+	}
+	if len(worktrees) == 0 {
+		return Worktree{}, fmt.Errorf("no worktrees found")
+	}
+	return worktrees[0], nil
 }
 
 // parseWorktrees parses the output of 'git worktree list --porcelain'
@@ -44,18 +90,27 @@ func parseWorktrees(output string) []Worktree {
 			continue
 		}
 
-		parts := strings.SplitN(line, " ", 2)
-		if len(parts) < 2 {
-			continue
-		}
+		// Attributes are either a bare label ("bare", "detached") or a
+		// label followed by a value ("locked" may also carry a reason)
+		key, value, _ := strings.Cut(line, " ")
 
-		switch parts[0] {
+		switch key {
 		case "worktree":
-			current.Path = parts[1]
+			current.Path = value
 		case "branch":
-			current.Branch = strings.TrimPrefix(parts[1], "refs/heads/")
+			current.Branch = strings.TrimPrefix(value, "refs/heads/")
 		case "HEAD":
-			current.Commit = parts[1]
+			current.Commit = value
+		case "bare":
+			current.IsBare = true
+		case "detached":
+			current.IsDetached = true
+		case "locked":
+			current.Locked = true
+			current.LockReason = value
+		case "prunable":
+			current.Prunable = true
+			current.PrunableReason = value
 		}
 	}
 
@@ -67,114 +122,111 @@ func parseWorktrees(output string) []Worktree {
 	return worktrees
 }
 
-// ListBranches returns a list of all branches
-func ListBranches() ([]string, error) {
-	cmd := exec.Command("git", "branch", "-a")
-	var out bytes.Buffer
-	cmd.Stdout = &out
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("failed to list branches: %w", err)
+// AddWorktree adds a new worktree
+func (r *Repository) AddWorktree(ctx context.Context, path, branch string) error {
+	if _, err := r.runner.Run(ctx, "worktree", "add", path, branch); err != nil {
+		return fmt.Errorf("failed to add worktree: %w", err)
 	}
+	return nil
+}
 
-	var branches []string
-	lines := strings.Split(out.String(), "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-		// Remove '* ' and '+ ' prefix and 'remotes/origin/' prefix
-		line = strings.TrimPrefix(line, "* ")
-		line = strings.TrimPrefix(line, "+ ")
-		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "remotes/origin/") {
-			line = strings.TrimPrefix(line, "remotes/origin/")
-		}
-		// Skip HEAD pointer
-		if !strings.Contains(line, "HEAD") && line != "" {
-			branches = append(branches, line)
-		}
+// AddWorktreeTracking creates branch as a local branch tracking the
+// remote-tracking branch remoteRef (e.g. "upstream/feature") and adds a
+// worktree for it
+func (r *Repository) AddWorktreeTracking(ctx context.Context, path, branch, remoteRef string) error {
+	if _, err := r.runner.Run(ctx, "worktree", "add", "--track", "-b", branch, path, remoteRef); err != nil {
+		return fmt.Errorf("failed to add worktree tracking '%s': %w", remoteRef, err)
 	}
+	return nil
+}
 
-	// Remove duplicates
-	seen := make(map[string]bool)
-	var uniqueBranches []string
-	for _, branch := range branches {
-		if !seen[branch] {
-			seen[branch] = true
-			uniqueBranches = append(uniqueBranches, branch)
-		}
+// AddWorktreeDetached adds a worktree with a detached HEAD at commit, for
+// working on a branch that is already checked out elsewhere
+func (r *Repository) AddWorktreeDetached(ctx context.Context, path, commit string) error {
+	if _, err := r.runner.Run(ctx, "worktree", "add", "--detach", path, commit); err != nil {
+		return fmt.Errorf("failed to add detached worktree: %w", err)
 	}
+	return nil
+}
 
-	// Sort branches: main/master first, then alphabetically
-	return sortBranches(uniqueBranches), nil
+// AddWorktreeWithNewBranch creates a new branch and adds a worktree for it
+func (r *Repository) AddWorktreeWithNewBranch(ctx context.Context, path, newBranch, baseBranch string) error {
+	if _, err := r.runner.Run(ctx, "worktree", "add", "-b", newBranch, path, baseBranch); err != nil {
+		return fmt.Errorf("failed to add worktree with new branch: %w", err)
+	}
+	return nil
 }
 
-// sortBranches sorts branches with main/master at the top
-func sortBranches(branches []string) []string {
-	var priorityBranches []string
-	var otherBranches []string
+// RemoveOptions controls how a worktree is removed
+type RemoveOptions struct {
+	// Force removes the worktree even if it has uncommitted changes,
+	// untracked files or a lock. Callers should show PreflightRemoval's
+	// findings before setting it.
+	Force bool
 
-	for _, branch := range branches {
-		if branch == "main" || branch == "master" {
-			priorityBranches = append(priorityBranches, branch)
-		} else {
-			otherBranches = append(otherBranches, branch)
-		}
-	}
+	// Branch is the branch checked out in the worktree. It is only needed
+	// when the branch is deleted as well.
+	Branch string
+	// DeleteBranch also deletes Branch after the worktree is removed
+	DeleteBranch BranchDeletion
+	// DeleteRemote also deletes the remote branch Branch tracks
+	DeleteRemote bool
+}
 
-	// Sort priority branches (main before master)
-	for i := 0; i < len(priorityBranches)-1; i++ {
-		for j := i + 1; j < len(priorityBranches); j++ {
-			if priorityBranches[i] == "master" && priorityBranches[j] == "main" {
-				priorityBranches[i], priorityBranches[j] = priorityBranches[j], priorityBranches[i]
-			}
-		}
+// RemoveWorktree removes a worktree and, if requested, its branch. An
+// error after the worktree itself was removed says so, since the removal
+// cannot be undone.
+func (r *Repository) RemoveWorktree(ctx context.Context, path string, opts RemoveOptions) error {
+	if opts.DeleteBranch == KeepBranch && opts.DeleteRemote {
+		return fmt.Errorf("deleting the remote branch requires deleting the local branch too")
+	}
+	if opts.DeleteBranch != KeepBranch && opts.Branch == "" {
+		return fmt.Errorf("no branch to delete for worktree at %s", path)
 	}
 
-	// Sort other branches alphabetically
-	for i := 0; i < len(otherBranches)-1; i++ {
-		for j := i + 1; j < len(otherBranches); j++ {
-			if otherBranches[i] > otherBranches[j] {
-				otherBranches[i], otherBranches[j] = otherBranches[j], otherBranches[i]
-			}
+	// The upstream is recorded in the branch config, which is gone once
+	// the local branch is deleted
+	var remote, remoteBranch string
+	if opts.DeleteRemote {
+		var err error
+		remote, remoteBranch, err = r.BranchRemote(ctx, opts.Branch)
+		if err != nil {
+			return err
+		}
+		if remote == "" {
+			return fmt.Errorf("branch '%s' has no remote upstream to delete", opts.Branch)
 		}
 	}
 
-	// Combine: priority branches first, then others
-	result := append(priorityBranches, otherBranches...)
-	return result
-}
+	args := []string{"worktree", "remove"}
+	if opts.Force {
+		// Given twice, --force also overrides a lock
+		args = append(args, "--force", "--force")
+	}
+	args = append(args, path)
+	if _, err := r.runner.Run(ctx, args...); err != nil {
+		return fmt.Errorf("failed to remove worktree: %w", err)
+	}
 
-// AddWorktree adds a new worktree
-func AddWorktree(path, branch string) error {
-	cmd := exec.Command("git", "worktree", "add", path, branch)
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to add worktree: %s", stderr.String())
+	if opts.DeleteBranch == KeepBranch {
+		return nil
+	}
+	if err := r.DeleteBranch(ctx, opts.Branch, opts.DeleteBranch == ForceDeleteBranch); err != nil {
+		return fmt.Errorf("worktree removed, but %w", err)
 	}
-	return nil
-}
 
-// AddWorktreeWithNewBranch creates a new branch and adds a worktree for it
-func AddWorktreeWithNewBranch(path, newBranch, baseBranch string) error {
-	cmd := exec.Command("git", "worktree", "add", "-b", newBranch, path, baseBranch)
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to add worktree with new branch: %s", stderr.String())
+	if opts.DeleteRemote {
+		if err := r.DeleteRemoteBranch(ctx, remote, remoteBranch); err != nil {
+			return fmt.Errorf("worktree and local branch removed, but %w", err)
+		}
 	}
 	return nil
 }
 
-// RemoveWorktree removes a worktree
-func RemoveWorktree(path string) error {
-	cmd := exec.Command("git", "worktree", "remove", path)
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to remove worktree: %s", stderr.String())
+// PruneWorktrees prunes administrative files of worktrees whose directories are gone
+func (r *Repository) PruneWorktrees(ctx context.Context) error {
+	if _, err := r.runner.Run(ctx, "worktree", "prune"); err != nil {
+		return fmt.Errorf("failed to prune worktrees: %w", err)
 	}
 	return nil
 }
@@ -184,17 +236,69 @@ type PathSuggestion struct {
 	Path        string
 	Description string
 	IsCustom    bool
+	Warning     string // Why the path is a poor place for the worktree; empty if it is free
+	Template    string // Absolute path template the path was made from; empty for numbered alternatives
+	NewDir      string // Missing directory above Path that adding the worktree creates; empty if the parent exists
 }
 
-// SuggestPaths generates path suggestions based on existing worktrees and the new branch
-func SuggestPaths(branch string) ([]PathSuggestion, error) {
-	worktrees, err := ListWorktrees()
+// SuggestPaths generates path suggestions based on existing worktrees and
+// the new branch. Suggestions are anchored at the main worktree, so they
+// are the same wherever rtr is started; they are relative to the current
+// directory only when that is a sibling of the target.
+func (r *Repository) SuggestPaths(ctx context.Context, branch string) ([]PathSuggestion, error) {
+	worktrees, err := r.ListWorktrees(ctx)
+	if err != nil {
+		return nil, err
+	}
+	root, err := r.Root(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	var suggestions []PathSuggestion
 	seen := make(map[string]bool)
+	// Suggestions are deduplicated by the directory they point to
+	key := func(path string) string {
+		if abs, err := r.AbsPath(path); err == nil {
+			return abs
+		}
+		return path
+	}
+
+	vars := TemplateVars{
+		Branch: branch,
+		Repo:   getRepoName(root.Main),
+		User:   currentUser(),
+		Date:   time.Now(),
+	}
+
+	// The team's canonical worktree root comes first
+	if root := r.config.WorktreeRoot; root != "" {
+		path := r.relPath(filepath.Join(root, branch))
+		seen[key(path)] = true
+		suggestions = append(suggestions, PathSuggestion{
+			Path:        path,
+			Description: "Configured worktree root",
+			IsCustom:    false,
+			Template:    filepath.Join(root, "{branch}"),
+		})
+	}
+	for _, template := range r.config.PathTemplates {
+		path, err := ExpandTemplate(template, vars)
+		if err != nil {
+			return nil, err
+		}
+		path = r.relPath(path)
+		if !seen[key(path)] {
+			seen[key(path)] = true
+			suggestions = append(suggestions, PathSuggestion{
+				Path:        path,
+				Description: "Configured template " + r.relPath(template),
+				IsCustom:    false,
+				Template:    template,
+			})
+		}
+	}
 
 	// Skip the main worktree (first one) for pattern analysis
 	if len(worktrees) > 1 {
@@ -202,13 +306,18 @@ func SuggestPaths(branch string) ([]PathSuggestion, error) {
 
 		// Generate suggestions from learned patterns
 		for _, pattern := range patterns {
-			path := applyPattern(pattern, branch)
-			if path != "" && !seen[path] {
-				seen[path] = true
+			path := applyPattern(pattern, vars)
+			if path == "" {
+				continue
+			}
+			path = r.relPath(path)
+			if !seen[key(path)] {
+				seen[key(path)] = true
 				suggestions = append(suggestions, PathSuggestion{
 					Path:        path,
-					Description: fmt.Sprintf("Learned pattern (%d similar)", pattern.Count),
+					Description: fmt.Sprintf("Learned pattern %s (%d similar)", r.relPath(pattern.Template), pattern.Count),
 					IsCustom:    false,
+					Template:    pattern.Template,
 				})
 			}
 		}
@@ -216,15 +325,18 @@ func SuggestPaths(branch string) ([]PathSuggestion, error) {
 
 	// Add default patterns if we don't have many suggestions
 	if len(suggestions) < 3 {
-		defaultSuggestions := getDefaultSuggestions(branch)
+		defaultSuggestions := r.getDefaultSuggestions(root.Main, vars)
 		for _, sug := range defaultSuggestions {
-			if !seen[sug.Path] {
-				seen[sug.Path] = true
+			if !seen[key(sug.Path)] {
+				seen[key(sug.Path)] = true
 				suggestions = append(suggestions, sug)
 			}
 		}
 	}
 
+	suggestions = r.rankPathsByHistory(suggestions, vars, seen, key)
+	suggestions = r.checkSuggestions(suggestions, worktrees, root.CommonDir, seen, key)
+
 	// Add custom input option at the end
 	suggestions = append(suggestions, PathSuggestion{
 		Path:        "",
@@ -235,116 +347,215 @@ func SuggestPaths(branch string) ([]PathSuggestion, error) {
 	return suggestions, nil
 }
 
+// checkSuggestions warns about the suggestions that collide with existing
+// files or worktrees and adds a numbered alternative after each of them.
+// It also notes the directories each suggestion would create.
+func (r *Repository) checkSuggestions(suggestions []PathSuggestion, worktrees []Worktree, commonDir string, seen map[string]bool, key func(string) string) []PathSuggestion {
+	checker := newPathChecker(worktrees, commonDir)
+
+	var checked []PathSuggestion
+	for _, sug := range suggestions {
+		sug.Warning = checker.check(key(sug.Path))
+		if dir := missingParent(key(sug.Path)); dir != "" {
+			sug.NewDir = r.relPath(dir)
+		}
+		checked = append(checked, sug)
+		if sug.Warning == "" {
+			continue
+		}
+
+		alt := checker.alternative(key(sug.Path))
+		if alt == "" {
+			continue
+		}
+		path := r.relPath(alt)
+		if !seen[key(path)] {
+			seen[key(path)] = true
+			checked = append(checked, PathSuggestion{
+				Path:        path,
+				Description: "Free alternative to " + sug.Path,
+				IsCustom:    false,
+			})
+		}
+	}
+	return checked
+}
+
 // pathPattern represents a detected path pattern
 type pathPattern struct {
-	Template string // e.g., "../{branch}", "../worktrees/{branch}"
+	Template string // e.g., "../{branch}", "../worktrees/{branch|slug}"
 	Count    int    // How many times this pattern appears
 }
 
-// analyzePathPatterns analyzes existing worktree paths to detect patterns
+// patternMatch is one way a worktree path can be explained by a template:
+// the path with the branch-derived part cut out (shape) and the strategy
+// that produced that part
+type patternMatch struct {
+	shape    string
+	strategy string
+}
+
+// analyzePathPatterns analyzes existing worktree paths to detect patterns.
+// Worktrees are grouped by where they live, and each group gets the slug
+// strategy that explains most of its paths, so "feature/auth" checked out
+// at ../feature-auth teaches that branches are flattened.
 func analyzePathPatterns(worktrees []Worktree) []pathPattern {
-	patternMap := make(map[string]int)
+	counts := make(map[string]int)           // Worktrees per shape
+	votes := make(map[string]map[string]int) // Worktrees per strategy per shape
 
 	for _, wt := range worktrees {
 		if wt.Branch == "" {
 			continue
 		}
 
-		// Try to extract pattern by replacing branch name
-		pattern := extractPattern(wt.Path, wt.Branch)
-		if pattern != "" {
-			patternMap[pattern]++
+		shapes := make(map[string]bool)
+		for _, match := range extractPatterns(wt.Path, wt.Branch) {
+			if !shapes[match.shape] {
+				shapes[match.shape] = true
+				counts[match.shape]++
+			}
+			if votes[match.shape] == nil {
+				votes[match.shape] = make(map[string]int)
+			}
+			votes[match.shape][match.strategy]++
 		}
 	}
 
-	// Convert map to sorted slice
 	var patterns []pathPattern
-	for template, count := range patternMap {
+	for shape, count := range counts {
+		best := ""
+		for _, strategy := range branchStrategies {
+			if votes[shape][strategy] > votes[shape][best] {
+				best = strategy
+			}
+		}
 		patterns = append(patterns, pathPattern{
-			Template: template,
+			Template: strings.Replace(shape, "\x00", best, 1),
 			Count:    count,
 		})
 	}
 
-	// Sort by count (most used first)
-	for i := 0; i < len(patterns)-1; i++ {
-		for j := i + 1; j < len(patterns); j++ {
-			if patterns[j].Count > patterns[i].Count {
-				patterns[i], patterns[j] = patterns[j], patterns[i]
-			}
+	// Most used first; the template breaks ties so the order is stable
+	sort.Slice(patterns, func(i, j int) bool {
+		if patterns[i].Count != patterns[j].Count {
+			return patterns[i].Count > patterns[j].Count
 		}
-	}
+		return patterns[i].Template < patterns[j].Template
+	})
 
 	return patterns
 }
 
-// extractPattern tries to extract a path pattern by replacing branch name with placeholder
-func extractPattern(path, branch string) string {
-	// Normalize branch name (replace slashes with dashes for comparison)
-	normalizedBranch := strings.ReplaceAll(branch, "/", "-")
+// extractPatterns returns the ways the worktree path of branch can be
+// explained by the slug strategies. Only the strategies producing the
+// longest part of the path are kept, as the most specific explanation.
+func extractPatterns(path, branch string) []patternMatch {
+	var matches []patternMatch
+	longest := 0
 
-	// Try different variations
-	variations := []string{
-		branch,
-		normalizedBranch,
-		strings.ToLower(branch),
-		strings.ToLower(normalizedBranch),
-	}
+	for _, strategy := range branchStrategies {
+		value, err := ExpandTemplate(strategy, TemplateVars{Branch: branch})
+		if err != nil || value == "" || len(value) < longest {
+			continue
+		}
+		i := strings.LastIndex(path, value)
+		if i < 0 || !isNameBoundary(path, i-1) || !isNameBoundary(path, i+len(value)) {
+			continue
+		}
 
-	for _, variant := range variations {
-		if strings.Contains(path, variant) {
-			pattern := strings.ReplaceAll(path, variant, "{branch}")
-			return pattern
+		if len(value) > longest {
+			longest = len(value)
+			matches = nil
 		}
+		matches = append(matches, patternMatch{
+			shape:    path[:i] + "\x00" + path[i+len(value):],
+			strategy: strategy,
+		})
 	}
+	return matches
+}
 
-	return ""
+// isNameBoundary reports whether position i of path is outside it or a
+// separator, so a branch only matches whole words of a path
+func isNameBoundary(path string, i int) bool {
+	if i < 0 || i >= len(path) {
+		return true
+	}
+	return strings.ContainsRune("/-_.@"+string(filepath.Separator), rune(path[i]))
 }
 
-// applyPattern applies a pattern template to a new branch name
-func applyPattern(pattern pathPattern, branch string) string {
-	// Keep branch hierarchy intact (e.g., feature/auth → feature/auth)
-	path := strings.ReplaceAll(pattern.Template, "{branch}", branch)
+// applyPattern applies a pattern template to a new branch, returning ""
+// if the template cannot be expanded
+func applyPattern(pattern pathPattern, vars TemplateVars) string {
+	path, err := ExpandTemplate(pattern.Template, vars)
+	if err != nil {
+		return ""
+	}
 	return path
 }
 
-// getDefaultSuggestions returns default path suggestions when no patterns are learned
-func getDefaultSuggestions(branch string) []PathSuggestion {
-	// Keep branch hierarchy intact (e.g., feature/auth → feature/auth)
-	// Get repository name for some suggestions
-	repoName := getRepoName()
+// getDefaultSuggestions returns default path suggestions, placed next to
+// the main worktree at mainRoot, when no patterns are learned
+func (r *Repository) getDefaultSuggestions(mainRoot string, vars TemplateVars) []PathSuggestion {
+	parent := filepath.Dir(mainRoot)
 
-	suggestions := []PathSuggestion{
-		{
-			Path:        fmt.Sprintf("../%s", branch),
-			Description: "Sibling directory (default)",
-			IsCustom:    false,
-		},
-		{
-			Path:        fmt.Sprintf("../worktrees/%s", branch),
-			Description: "Organized in worktrees folder",
-			IsCustom:    false,
-		},
+	type pathDefault struct {
+		template    string
+		description string
+	}
+	// Flattened first (feature/auth → feature-auth), so a new branch does
+	// not leave a stray feature/ directory next to the repository
+	defaults := []pathDefault{
+		{"{branch|slug}", "Sibling directory (default)"},
 	}
+	if vars.Repo != "" {
+		defaults = append(defaults, pathDefault{"{repo}-{branch|slug}", "With repository name prefix"})
+	}
+	defaults = append(defaults,
+		pathDefault{filepath.Join("worktrees", "{branch}"), "Organized in worktrees folder"},
+		// Keep branch hierarchy intact (e.g., feature/auth → feature/auth)
+		pathDefault{"{branch}", "Sibling directory keeping the branch hierarchy"},
+	)
 
-	if repoName != "" {
+	var suggestions []PathSuggestion
+	for _, d := range defaults {
+		template := filepath.Join(parent, d.template)
+		path, err := ExpandTemplate(template, vars)
+		if err != nil {
+			continue
+		}
 		suggestions = append(suggestions, PathSuggestion{
-			Path:        fmt.Sprintf("../%s-%s", repoName, branch),
-			Description: "With repository name prefix",
+			Path:        r.relPath(path),
+			Description: d.description,
 			IsCustom:    false,
+			Template:    template,
 		})
 	}
-
 	return suggestions
 }
 
-// getRepoName tries to get the current repository name
-func getRepoName() string {
-	cwd, err := os.Getwd()
+// relPath expresses the absolute path relative to the current directory
+// when it is below the same parent, keeping suggestions short, and keeps
+// it absolute otherwise
+func (r *Repository) relPath(path string) string {
+	dir, err := r.AbsPath(".")
 	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, "../..") {
+		return path
+	}
+	return rel
+}
+
+// getRepoName returns the repository name: the directory name of the
+// main worktree, without the .git suffix of a bare repository
+func getRepoName(mainRoot string) string {
+	if mainRoot == "" {
 		return ""
 	}
-	return filepath.Base(cwd)
+	return strings.TrimSuffix(filepath.Base(mainRoot), ".git")
 }
 
 // BranchNameSuggestion represents a suggested branch name
@@ -354,53 +565,38 @@ type BranchNameSuggestion struct {
 	IsCustom    bool
 }
 
-// SuggestBranchNames generates branch name suggestions based on existing branches
-func SuggestBranchNames() ([]BranchNameSuggestion, error) {
-	branches, err := ListBranches()
+// commonPrefixes are suggested when the config declares no prefixes
+var commonPrefixes = []config.Prefix{
+	{Name: "feature/", Description: "New feature"},
+	{Name: "bugfix/", Description: "Bug fix"},
+	{Name: "hotfix/", Description: "Urgent fix"},
+	{Name: "release/", Description: "Release branch"},
+	{Name: "refactor/", Description: "Code refactoring"},
+	{Name: "chore/", Description: "Maintenance task"},
+}
+
+// SuggestBranchNames generates branch name suggestions based on existing
+// branches, ranked by how often and how recently each prefix was chosen,
+// how many branches use it, how recent they are, and its place in the
+// config, so the order is the same every time
+func (r *Repository) SuggestBranchNames(ctx context.Context) ([]BranchNameSuggestion, error) {
+	branches, err := r.ListBranches(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	var suggestions []BranchNameSuggestion
-	seen := make(map[string]bool)
-
-	// Analyze existing branches for patterns
-	prefixCounts := analyzeBranchPrefixes(branches)
-
-	// Add learned prefix patterns
-	for prefix, count := range prefixCounts {
-		if count >= 2 && !seen[prefix] { // Only suggest if used at least twice
-			seen[prefix] = true
-			suggestions = append(suggestions, BranchNameSuggestion{
-				Name:        prefix,
-				Description: fmt.Sprintf("Learned pattern (%d branches)", count),
-				IsCustom:    false,
-			})
-		}
+	// Prefixes declared in the config replace the built-in ones and are
+	// the only ones suggested
+	prefixes := r.config.Prefixes
+	configured := len(prefixes) > 0
+	if !configured {
+		prefixes = commonPrefixes
 	}
 
-	// Add common prefixes
-	commonPrefixes := []struct {
-		prefix string
-		desc   string
-	}{
-		{"feature/", "New feature"},
-		{"bugfix/", "Bug fix"},
-		{"hotfix/", "Urgent fix"},
-		{"release/", "Release branch"},
-		{"refactor/", "Code refactoring"},
-		{"chore/", "Maintenance task"},
-	}
-
-	for _, cp := range commonPrefixes {
-		if !seen[cp.prefix] {
-			seen[cp.prefix] = true
-			suggestions = append(suggestions, BranchNameSuggestion{
-				Name:        cp.prefix,
-				Description: cp.desc,
-				IsCustom:    false,
-			})
-		}
+	now := time.Now()
+	var suggestions []BranchNameSuggestion
+	for _, rank := range rankPrefixes(analyzeBranchPrefixes(branches), prefixes, configured, r.history, now) {
+		suggestions = append(suggestions, rank.suggestion(now))
 	}
 
 	// Add custom input option
@@ -412,23 +608,3 @@ func SuggestBranchNames() ([]BranchNameSuggestion, error) {
 
 	return suggestions, nil
 }
-
-// analyzeBranchPrefixes analyzes branch names to find common prefixes
-func analyzeBranchPrefixes(branches []string) map[string]int {
-	prefixCounts := make(map[string]int)
-
-	for _, branch := range branches {
-		// Skip main/master branches
-		if branch == "main" || branch == "master" {
-			continue
-		}
-
-		// Look for patterns like "feature/", "bugfix/", etc.
-		if idx := strings.Index(branch, "/"); idx > 0 {
-			prefix := branch[:idx+1]
-			prefixCounts[prefix]++
-		}
-	}
-
-	return prefixCounts
-}
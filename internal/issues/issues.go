@@ -0,0 +1,296 @@
+package issues
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/FScoward/rakutree/internal/config"
+	"github.com/FScoward/rakutree/internal/git"
+)
+
+// maxIssues bounds how many issues are fetched
+const maxIssues = 50
+
+// maxTitleLength bounds the part of a branch name taken from the title
+const maxTitleLength = 40
+
+// defaultJQL selects the user's open Jira issues
+const defaultJQL = "assignee = currentUser() AND resolution = Unresolved ORDER BY updated DESC"
+
+// Issue is an issue of a tracker
+type Issue struct {
+	Key   string // e.g. "PROJ-123" or "42"
+	Title string
+	URL   string
+}
+
+// BranchName returns the branch name for issue: the key followed by the
+// title slugified, e.g. "PROJ-123-short-title"
+func (i Issue) BranchName() string {
+	title := strings.ToLower(i.Title)
+	var b strings.Builder
+	dash := false
+	for _, c := range title {
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') {
+			b.WriteRune(c)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	slug := strings.TrimSuffix(b.String(), "-")
+
+	// Cut long titles at a word boundary
+	if len(slug) > maxTitleLength {
+		slug = slug[:maxTitleLength]
+		if i := strings.LastIndex(slug, "-"); i > 0 {
+			slug = slug[:i]
+		}
+	}
+
+	name := i.Key
+	if slug != "" {
+		name += "-" + slug
+	}
+	return git.SanitizeBranchName(name)
+}
+
+// Tracker lists issues from an issue tracker's HTTP API. It is a
+// git.BranchNameProvider.
+type Tracker struct {
+	cfg    config.IssueTracker
+	token  string
+	client *http.Client
+}
+
+// New returns the tracker described by cfg
+func New(cfg config.IssueTracker) (*Tracker, error) {
+	switch cfg.Type {
+	case "github":
+		if cfg.URL == "" {
+			cfg.URL = "https://api.github.com"
+		}
+	case "gitlab":
+		if cfg.URL == "" {
+			cfg.URL = "https://gitlab.com"
+		}
+	case "jira":
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("issue_tracker: jira needs a url in the user config")
+		}
+	default:
+		return nil, fmt.Errorf("issue_tracker: unknown type '%s' (supported: github, gitlab, jira)", cfg.Type)
+	}
+	if cfg.Project == "" && cfg.Type != "jira" {
+		return nil, fmt.Errorf("issue_tracker: %s needs a project", cfg.Type)
+	}
+
+	token := cfg.Token
+	if cfg.TokenEnv != "" {
+		token = os.Getenv(cfg.TokenEnv)
+	}
+	return &Tracker{
+		cfg:    cfg,
+		token:  token,
+		client: &http.Client{Timeout: 15 * time.Second},
+	}, nil
+}
+
+// Name describes the tracker, e.g. "GitHub issues"
+func (t *Tracker) Name() string {
+	switch t.cfg.Type {
+	case "github":
+		return "GitHub issues"
+	case "gitlab":
+		return "GitLab issues"
+	}
+	return "Jira issues"
+}
+
+// SuggestBranchNames returns a branch name for each issue, starting with
+// the configured prefix, or prefix if none is configured
+func (t *Tracker) SuggestBranchNames(ctx context.Context, prefix string) ([]git.BranchNameSuggestion, error) {
+	if t.cfg.Prefix != "" {
+		prefix = t.cfg.Prefix
+	}
+	issues, err := t.Issues(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := make([]git.BranchNameSuggestion, 0, len(issues))
+	for _, issue := range issues {
+		label := issue.Key
+		if t.cfg.Type != "jira" {
+			// GitHub and GitLab number their issues
+			label = "#" + label
+		}
+		suggestions = append(suggestions, git.BranchNameSuggestion{
+			Name:        prefix + issue.BranchName(),
+			Description: fmt.Sprintf("%s %s", label, issue.Title),
+			IsCustom:    false,
+		})
+	}
+	return suggestions, nil
+}
+
+// Issues fetches the open issues matching the configured query
+func (t *Tracker) Issues(ctx context.Context) ([]Issue, error) {
+	switch t.cfg.Type {
+	case "github":
+		return t.githubIssues(ctx)
+	case "gitlab":
+		return t.gitlabIssues(ctx)
+	}
+	return t.jiraIssues(ctx)
+}
+
+func (t *Tracker) githubIssues(ctx context.Context) ([]Issue, error) {
+	endpoint := fmt.Sprintf("%s/repos/%s/issues", strings.TrimRight(t.cfg.URL, "/"), t.cfg.Project)
+	query, err := t.query(url.Values{"state": {"open"}, "per_page": {strconv.Itoa(maxIssues)}})
+	if err != nil {
+		return nil, err
+	}
+
+	var items []struct {
+		Number      int       `json:"number"`
+		Title       string    `json:"title"`
+		HTMLURL     string    `json:"html_url"`
+		PullRequest *struct{} `json:"pull_request"`
+	}
+	if err := t.get(ctx, endpoint+"?"+query.Encode(), &items); err != nil {
+		return nil, err
+	}
+
+	var issues []Issue
+	for _, item := range items {
+		// The issues API lists pull requests too
+		if item.PullRequest != nil {
+			continue
+		}
+		issues = append(issues, Issue{Key: strconv.Itoa(item.Number), Title: item.Title, URL: item.HTMLURL})
+	}
+	return issues, nil
+}
+
+func (t *Tracker) gitlabIssues(ctx context.Context) ([]Issue, error) {
+	endpoint := fmt.Sprintf("%s/api/v4/projects/%s/issues", strings.TrimRight(t.cfg.URL, "/"), url.PathEscape(t.cfg.Project))
+	query, err := t.query(url.Values{"state": {"opened"}, "per_page": {strconv.Itoa(maxIssues)}})
+	if err != nil {
+		return nil, err
+	}
+
+	var items []struct {
+		IID    int    `json:"iid"`
+		Title  string `json:"title"`
+		WebURL string `json:"web_url"`
+	}
+	if err := t.get(ctx, endpoint+"?"+query.Encode(), &items); err != nil {
+		return nil, err
+	}
+
+	issues := make([]Issue, 0, len(items))
+	for _, item := range items {
+		issues = append(issues, Issue{Key: strconv.Itoa(item.IID), Title: item.Title, URL: item.WebURL})
+	}
+	return issues, nil
+}
+
+func (t *Tracker) jiraIssues(ctx context.Context) ([]Issue, error) {
+	base := strings.TrimRight(t.cfg.URL, "/")
+	jql := t.cfg.Query
+	if jql == "" {
+		jql = defaultJQL
+		if t.cfg.Project != "" {
+			jql = fmt.Sprintf("project = %q AND %s", t.cfg.Project, jql)
+		}
+	}
+	query := url.Values{"jql": {jql}, "fields": {"summary"}, "maxResults": {strconv.Itoa(maxIssues)}}
+
+	var result struct {
+		Issues []struct {
+			Key    string `json:"key"`
+			Fields struct {
+				Summary string `json:"summary"`
+			} `json:"fields"`
+		} `json:"issues"`
+	}
+	if err := t.get(ctx, base+jiraSearchPath(base)+"?"+query.Encode(), &result); err != nil {
+		return nil, err
+	}
+
+	issues := make([]Issue, 0, len(result.Issues))
+	for _, item := range result.Issues {
+		issues = append(issues, Issue{Key: item.Key, Title: item.Fields.Summary, URL: base + "/browse/" + item.Key})
+	}
+	return issues, nil
+}
+
+// jiraSearchPath returns the search endpoint of the Jira at base. Jira
+// Cloud retired /rest/api/2/search; Server and Data Center only have it.
+func jiraSearchPath(base string) string {
+	if u, err := url.Parse(base); err == nil && strings.HasSuffix(strings.ToLower(u.Hostname()), ".atlassian.net") {
+		return "/rest/api/3/search/jql"
+	}
+	return "/rest/api/2/search"
+}
+
+// query adds the configured URL query parameters to defaults
+func (t *Tracker) query(defaults url.Values) (url.Values, error) {
+	extra, err := url.ParseQuery(t.cfg.Query)
+	if err != nil {
+		return nil, fmt.Errorf("issue_tracker: invalid query '%s': %w", t.cfg.Query, err)
+	}
+	for key, values := range extra {
+		defaults[key] = values
+	}
+	return defaults, nil
+}
+
+// get fetches endpoint with the tracker's credentials and decodes the JSON
+// response into v
+func (t *Tracker) get(ctx context.Context, endpoint string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to load issues: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if t.token != "" {
+		switch {
+		case t.cfg.Type == "gitlab":
+			req.Header.Set("PRIVATE-TOKEN", t.token)
+		case t.cfg.User != "":
+			req.SetBasicAuth(t.cfg.User, t.token)
+		default:
+			req.Header.Set("Authorization", "Bearer "+t.token)
+		}
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to load issues: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+		msg := strings.TrimSpace(strings.SplitN(string(body), "\n", 2)[0])
+		if msg == "" {
+			return fmt.Errorf("failed to load issues: %s returned %s", req.URL.Host, resp.Status)
+		}
+		return fmt.Errorf("failed to load issues: %s returned %s: %s", req.URL.Host, resp.Status, msg)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to load issues: unexpected response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// CdFileEnv names the environment variable set by the shell wrapper. When
+// present, rtr writes the directory the shell should change into to that file.
+const CdFileEnv = "RAKUTREE_CD_FILE"
+
+const posixWrapper = `# rakutree shell integration
+# Add to your shell rc file: eval "$(rtr shell-init %[1]s)"
+rtr() {
+  local cd_file dir rtr_status
+  cd_file="$(mktemp -t rakutree.XXXXXX)" || return
+  RAKUTREE_CD_FILE="$cd_file" command rtr "$@"
+  rtr_status=$?
+  dir="$(cat "$cd_file")"
+  rm -f "$cd_file"
+  if [ -n "$dir" ] && [ -d "$dir" ]; then
+    cd "$dir" || return
+  fi
+  return $rtr_status
+}
+`
+
+const fishWrapper = `# rakutree shell integration
+# Add to ~/.config/fish/config.fish: rtr shell-init fish | source
+function rtr
+    set -l cd_file (mktemp -t rakutree.XXXXXX); or return
+    env RAKUTREE_CD_FILE=$cd_file rtr $argv
+    set -l rtr_status $status
+    set -l dir (cat $cd_file)
+    rm -f $cd_file
+    if test -n "$dir"; and test -d "$dir"
+        cd $dir
+    end
+    return $rtr_status
+end
+`
+
+var wrappers = map[string]string{
+	"bash": fmt.Sprintf(posixWrapper, "bash"),
+	"zsh":  fmt.Sprintf(posixWrapper, "zsh"),
+	"fish": fishWrapper,
+}
+
+// Init returns the wrapper function source for the given shell
+func Init(shell string) (string, error) {
+	script, ok := wrappers[shell]
+	if !ok {
+		return "", fmt.Errorf("unsupported shell %q (supported: %v)", shell, Supported())
+	}
+	return script, nil
+}
+
+// Supported returns the names of shells with an available wrapper
+func Supported() []string {
+	names := make([]string, 0, len(wrappers))
+	for name := range wrappers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Wrapped reports whether rtr was started through the shell wrapper, which
+// changes into the recorded target
+func Wrapped() bool {
+	return os.Getenv(CdFileEnv) != ""
+}
+
+// RecordTarget tells the shell wrapper to change into dir once rtr exits.
+// It is a no-op when rtr was not started through the wrapper.
+func RecordTarget(dir string) error {
+	cdFile := os.Getenv(CdFileEnv)
+	if cdFile == "" || dir == "" {
+		return nil
+	}
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", dir, err)
+	}
+	if err := os.WriteFile(cdFile, []byte(absDir), 0o600); err != nil {
+		return fmt.Errorf("failed to record target directory: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// File returns the path of the state file name:
+// $XDG_STATE_HOME/rakutree/<name>, or ~/.local/state/rakutree/<name>
+func File(name string) (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "rakutree", name), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "state", "rakutree", name), nil
+}
+
+// WriteFile replaces the file at path with content, creating its
+// directory. The content is written to a temporary file that is renamed
+// into place, so readers never see a partly written file.
+func WriteFile(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,41 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFile(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", "/xdg/state")
+	if got, err := File("history.json"); err != nil || got != "/xdg/state/rakutree/history.json" {
+		t.Errorf("File() = %q, %v", got, err)
+	}
+
+	t.Setenv("XDG_STATE_HOME", "")
+	t.Setenv("HOME", "/home/me")
+	if got, err := File("history.json"); err != nil || got != "/home/me/.local/state/rakutree/history.json" {
+		t.Errorf("File() without XDG_STATE_HOME = %q, %v", got, err)
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rakutree", "state.json")
+
+	for _, content := range []string{`{"a":1}`, `{}`} {
+		if err := WriteFile(path, []byte(content)); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+		got, err := os.ReadFile(path)
+		if err != nil || string(got) != content {
+			t.Errorf("file = %q, %v; want %q", got, err, content)
+		}
+	}
+
+	// No temporary files are left behind
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("directory has %d entries, want only the file", len(entries))
+	}
+}
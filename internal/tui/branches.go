@@ -0,0 +1,138 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/FScoward/rakutree/internal/git"
+	"github.com/FScoward/rakutree/internal/history"
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// setBranchItems fills the list with the loaded branches for target in
+// the current sort order
+func (m *Model) setBranchItems(target viewState) {
+	desc := ""
+	if target == newBranchBaseView {
+		desc = "Base branch for new branch"
+	}
+
+	branches := git.SortBranches(m.branches, m.branchSort, m.userEmail)
+	now := time.Now()
+	items := make([]list.Item, 0, len(branches))
+	for _, branch := range branches {
+		items = append(items, branchItem(branch, desc, now))
+	}
+	if target == newBranchBaseView {
+		items = withRememberedBases(items, m.repo.History(), now)
+		items = withDefaultBase(items, m.repo.Config().DefaultBase)
+	}
+	m.list.SetItems(items)
+}
+
+// cycleBranchSort switches the branch list to the next sort mode, keeping
+// the selected branch selected
+func (m *Model) cycleBranchSort() {
+	var selected string
+	if it, ok := m.list.SelectedItem().(item); ok {
+		selected = it.value
+	}
+
+	m.branchSort = m.branchSort.Next()
+	m.setBranchItems(m.state)
+	for i, it := range m.list.Items() {
+		if it.(item).value == selected {
+			m.list.Select(i)
+			break
+		}
+	}
+}
+
+// withDefaultBase moves the configured default base branch to the top of
+// the base branch list so it is preselected
+func withDefaultBase(items []list.Item, defaultBase string) []list.Item {
+	for i, it := range items {
+		if it.(item).value != defaultBase {
+			continue
+		}
+		top := it.(item)
+		top.desc = strings.Replace(top.desc, "Base branch for new branch", "Default base branch (config)", 1)
+		sorted := append([]list.Item{top}, items[:i]...)
+		return append(sorted, items[i+1:]...)
+	}
+	return items
+}
+
+// withRememberedBases moves the base branches chosen before to the top of
+// the base branch list, by frecency
+func withRememberedBases(items []list.Item, h *history.History, now time.Time) []list.Item {
+	for i, it := range items {
+		e, ok := h.Lookup(history.Bases, it.(item).value)
+		if !ok {
+			continue
+		}
+		used := "Used as base once"
+		if e.Count > 1 {
+			used = fmt.Sprintf("Used as base %d times", e.Count)
+		}
+		it := it.(item)
+		it.desc = strings.Replace(it.desc, "Base branch for new branch", used, 1)
+		items[i] = it
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return h.Frecency(history.Bases, items[i].(item).value, now) > h.Frecency(history.Bases, items[j].(item).value, now)
+	})
+	return items
+}
+
+// branchItem renders a branch for the branch pickers: a ● marks branches
+// checked out in a worktree, remote-only branches get a badge, and the
+// description ends with the age of the last commit. The item value is
+// what git resolves the branch by.
+func branchItem(b git.Branch, desc string, now time.Time) item {
+	title := b.Name
+	if !b.IsLocal {
+		title = fmt.Sprintf("%s  [remote]", b.Ref())
+		if desc == "" {
+			desc = "Remote-only; creates a local tracking branch"
+		}
+	} else if desc == "" && b.Upstream != "" {
+		desc = "Tracks " + b.Upstream
+	}
+	if b.CheckedOut != "" {
+		title = "● " + title
+		checkedOut := "checked out at " + b.CheckedOut
+		if desc == "" {
+			desc = checkedOut
+		} else {
+			desc = fmt.Sprintf("%s | %s", desc, checkedOut)
+		}
+	}
+
+	if !b.LastCommit.IsZero() {
+		age := git.RelativeAge(b.LastCommit, now)
+		if desc == "" {
+			desc = "Last commit " + age
+		} else {
+			desc = fmt.Sprintf("%s | %s", desc, age)
+		}
+	}
+	return item{title: title, desc: desc, value: b.Ref()}
+}
+
+// checkBranchName validates the typed branch name against git's rules and
+// the existing branches. If it is invalid, slug is a sanitized name that
+// would be accepted, or "" if there is none.
+func (m Model) checkBranchName() (slug string, err error) {
+	name := m.branchNameInput.Value()
+	if err := git.CheckNewBranchName(name, m.branches); err != nil {
+		slug = git.SanitizeBranchName(name)
+		if slug == name || git.CheckNewBranchName(slug, m.branches) != nil {
+			slug = ""
+		}
+		return slug, err
+	}
+	return "", nil
+}
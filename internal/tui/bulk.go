@@ -0,0 +1,173 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/FScoward/rakutree/internal/git"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// setRemoveItems renders the remove list with a mark next to each
+// worktree, keeping the cursor where it is
+func (m *Model) setRemoveItems() {
+	items := make([]list.Item, len(m.worktrees))
+	for i, wt := range m.worktrees {
+		branch := wt.Branch
+		if branch == "" {
+			branch = "detached"
+		}
+		desc := fmt.Sprintf("Branch: %s", branch)
+		if wt.Branch != "" && m.mergedBranches[wt.Branch] {
+			desc += " | merged"
+		}
+		if reason, ok := m.staleReasons[wt.Path]; ok {
+			desc += " | " + reason
+		}
+		items[i] = item{
+			title: fmt.Sprintf("%s %s", checkbox(m.marked[wt.Path]), wt.Path),
+			desc:  withAnnotations(desc, wt),
+			value: wt.Path,
+		}
+	}
+	m.list.SetItems(items)
+}
+
+// toggleMarked marks or unmarks the worktree under the cursor
+func (m *Model) toggleMarked() {
+	selected := m.list.SelectedItem()
+	if selected == nil {
+		return
+	}
+
+	path := selected.(item).value
+	if m.marked[path] {
+		delete(m.marked, path)
+	} else {
+		m.marked[path] = true
+	}
+	m.setRemoveItems()
+}
+
+// markMerged marks every worktree whose branch is fully merged
+func (m *Model) markMerged() {
+	count := 0
+	for _, wt := range m.worktrees {
+		if wt.Branch != "" && m.mergedBranches[wt.Branch] {
+			m.marked[wt.Path] = true
+			count++
+		}
+	}
+	m.message = fmt.Sprintf("Marked %d merged worktrees", count)
+	m.setRemoveItems()
+}
+
+// markedWorktrees returns the marked worktrees in list order
+func (m Model) markedWorktrees() []git.Worktree {
+	var worktrees []git.Worktree
+	for _, wt := range m.worktrees {
+		if m.marked[wt.Path] {
+			worktrees = append(worktrees, wt)
+		}
+	}
+	return worktrees
+}
+
+// needsForceCount returns how many of the checked worktrees git would
+// refuse to remove without --force
+func (m Model) needsForceCount() int {
+	count := 0
+	for _, p := range m.bulkPreflights {
+		if p.NeedsForce() {
+			count++
+		}
+	}
+	return count
+}
+
+// handleConfirmBulkRemoveKey handles the batch removal confirmation dialog
+func (m Model) handleConfirmBulkRemoveKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y":
+		return m, m.removeWorktrees(m.bulkPreflights, false, m.cleanup)
+	case "f":
+		if m.needsForceCount() > 0 {
+			return m, m.removeWorktrees(m.bulkPreflights, true, m.cleanup)
+		}
+	case "n", "esc", "q", "ctrl+c":
+		return m.cancelRemoval()
+	}
+	return m, nil
+}
+
+// handleBulkResultKey returns to the menu from the batch result summary
+func (m Model) handleBulkResultKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter", "esc", "q", "ctrl+c":
+		m.state = menuView
+		m.resetMenuItems()
+	}
+	return m, nil
+}
+
+// confirmBulkRemoveDialog lists the preflight findings of every marked worktree
+func (m Model) confirmBulkRemoveDialog() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(fmt.Sprintf("Remove %d worktrees?", len(m.bulkPreflights))))
+	b.WriteString("\n\n")
+
+	for _, p := range m.bulkPreflights {
+		risks := p.Risks()
+		if len(risks) == 0 {
+			b.WriteString(successStyle.Render("✓ " + p.Worktree.Path))
+			b.WriteString("\n")
+			continue
+		}
+		b.WriteString(warningStyle.Render("⚠ " + p.Worktree.Path))
+		b.WriteString("\n")
+		for _, risk := range risks {
+			b.WriteString("    " + risk + "\n")
+		}
+	}
+	b.WriteString("\n")
+
+	if n := m.needsForceCount(); n > 0 {
+		b.WriteString(fmt.Sprintf("git refuses to remove %d of these without --force.\n", n))
+		b.WriteString("Press y to remove the others, f to force remove all and discard the above,\n")
+		b.WriteString("n or ESC to cancel")
+	} else {
+		b.WriteString("Press y to remove all, n or ESC to cancel")
+	}
+
+	return modalStyle.Render(b.String())
+}
+
+// bulkResultSummary reports the outcome of each removal in the batch
+func (m Model) bulkResultSummary() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Removal results"))
+	b.WriteString("\n\n")
+
+	removed := 0
+	for _, r := range m.bulkResults {
+		switch {
+		case r.skipped != "":
+			b.WriteString(warningStyle.Render(fmt.Sprintf("- %s (skipped: %s)", r.path, r.skipped)))
+		case r.err != nil:
+			b.WriteString(errorStyle.Render(fmt.Sprintf("✗ %s: %v", r.path, r.err)))
+		default:
+			removed++
+			b.WriteString(successStyle.Render("✓ " + r.path))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString(fmt.Sprintf("\nRemoved %d of %d worktrees\n\n", removed, len(m.bulkResults)))
+	if len(m.hookLog) > 0 {
+		b.WriteString(m.hookLogPane())
+		b.WriteString("\n\n")
+	}
+	b.WriteString("Press Enter or ESC to return to the menu")
+	return b.String()
+}
@@ -0,0 +1,126 @@
+package tui
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxHookLogLines bounds how much hook output is kept for the log pane
+const maxHookLogLines = 500
+
+var logPaneStyle = lipgloss.NewStyle().
+	Border(lipgloss.NormalBorder()).
+	BorderForeground(lipgloss.Color("#626262")).
+	Padding(0, 1)
+
+// hookLogMsg carries one line of hook output of operation id
+type hookLogMsg struct {
+	id   int
+	line string
+	logs <-chan string
+}
+
+// waitForHookLog delivers the next line from logs, or nothing once the
+// operation has closed it
+func waitForHookLog(id int, logs <-chan string) tea.Cmd {
+	return func() tea.Msg {
+		line, ok := <-logs
+		if !ok {
+			return nil
+		}
+		return hookLogMsg{id: id, line: line, logs: logs}
+	}
+}
+
+// logWriter forwards the lines written to it to a channel. Sending gives
+// up once ctx is done so a cancelled operation never blocks on the log.
+type logWriter struct {
+	ctx  context.Context
+	logs chan<- string
+	buf  []byte
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.send(string(w.buf[:i]))
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// flush sends a trailing line that has no newline
+func (w *logWriter) flush() {
+	if len(w.buf) > 0 {
+		w.send(string(w.buf))
+		w.buf = nil
+	}
+}
+
+func (w *logWriter) send(line string) {
+	select {
+	case w.logs <- line:
+	case <-w.ctx.Done():
+	}
+}
+
+// startHookOp is startOp for operations that run hooks: what fn writes to
+// log is streamed into the log pane while it runs
+func (m *Model) startHookOp(label string, fn func(ctx context.Context, log io.Writer) tea.Msg) tea.Cmd {
+	logs := make(chan string)
+	cmd := m.startOp(label, func(ctx context.Context) tea.Msg {
+		defer close(logs)
+		w := &logWriter{ctx: ctx, logs: logs}
+		msg := fn(ctx, w)
+		w.flush()
+		return msg
+	})
+	return tea.Batch(cmd, waitForHookLog(m.opID, logs))
+}
+
+// appendHookLog adds a line of hook output, dropping the oldest lines
+// beyond maxHookLogLines
+func (m *Model) appendHookLog(line string) {
+	m.hookLog = append(m.hookLog, strings.TrimRight(line, "\r"))
+	if len(m.hookLog) > maxHookLogLines {
+		m.hookLog = m.hookLog[len(m.hookLog)-maxHookLogLines:]
+	}
+}
+
+// handleHookLogKey returns to the menu once the hook output has been read
+func (m Model) handleHookLogKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter", "esc", "q", "ctrl+c":
+		m.hookLog = nil
+		m.state = menuView
+		m.resetMenuItems()
+	}
+	return m, nil
+}
+
+// hookLogPane renders the tail of the hook output that fits the window
+func (m Model) hookLogPane() string {
+	lines := m.hookLog
+	height := m.height - 12
+	if height < 5 {
+		height = 5
+	}
+	if len(lines) > height {
+		lines = lines[len(lines)-height:]
+	}
+
+	pane := logPaneStyle
+	if m.width > 4 {
+		pane = pane.Width(m.width - 4)
+	}
+	return pane.Render(strings.Join(lines, "\n"))
+}
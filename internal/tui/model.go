@@ -1,12 +1,16 @@
 package tui
 
 import (
+	"context"
 	"fmt"
-	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/FScoward/rakutree/internal/files"
 	"github.com/FScoward/rakutree/internal/git"
+	"github.com/FScoward/rakutree/internal/shell"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -25,6 +29,13 @@ const (
 	pathSelectView
 	customPathView
 	removeView
+	confirmRemoveView
+	confirmForceDeleteBranchView
+	confirmBulkRemoveView
+	bulkResultView
+	hookLogView
+	checkedOutView
+	issuePickerView
 )
 
 var (
@@ -45,11 +56,20 @@ var (
 	successStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#04B575")).
 			Bold(true)
+
+	warningStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FFA500"))
+
+	modalStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("#7D56F4")).
+			Padding(1, 2)
 )
 
 type item struct {
 	title string
 	desc  string
+	value string // Identifies the entry when title is decorated (e.g. marked paths)
 }
 
 func (i item) Title() string       { return i.title }
@@ -57,26 +77,54 @@ func (i item) Description() string { return i.desc }
 func (i item) FilterValue() string { return i.title }
 
 type Model struct {
+	repo                  *git.Repository
 	state                 viewState
 	list                  list.Model
 	pathInput             textinput.Model
 	branchNameInput       textinput.Model
+	spinner               spinner.Model
 	worktrees             []git.Worktree
-	branches              []string
+	branches              []git.Branch
 	selectedBranch        string
+	trackRef              string // Remote branch the selected branch is created from, if remote-only
+	detach                bool   // Add a detached worktree at the selected branch's commit
+	checkedOutAt          string // Worktree that already has the selected branch checked out
+	branchSort            git.BranchSort
+	userEmail             string
 	baseBranch            string
 	selectedPrefix        string
 	isNewBranch           bool
 	pathSuggestions       []git.PathSuggestion
 	branchNameSuggestions []git.BranchNameSuggestion
+	preflight             git.RemovalPreflight
+	forceRemove           bool
+	deleteBranch          bool
+	deleteRemote          bool
+	marked                map[string]bool
+	mergedBranches        map[string]bool
+	bulkPreflights        []git.RemovalPreflight
+	bulkResults           []bulkRemoveResult
+	filePlan              files.Plan
+	hookLog               []string // Output of the last operation's file copies and hooks
+	cleanup               bool
+	staleReasons          map[string]string
+	busy                  bool
+	busyLabel             string
+	opID                  int
+	cancel                context.CancelFunc
+	initCmd               tea.Cmd
 	err                   error
 	message               string
 	quitting              bool
+	switchMode            bool
+	jumpTarget            string
+	jumped                bool // The user chose to switch to jumpTarget
 	width                 int
 	height                int
 }
 
-func NewModel() Model {
+// NewModel returns the main menu model operating on repo
+func NewModel(repo *git.Repository) Model {
 	ti := textinput.New()
 	ti.Placeholder = "Enter worktree path (e.g., ../feature-branch)"
 	ti.Focus()
@@ -88,10 +136,15 @@ func NewModel() Model {
 	bi.CharLimit = 256
 	bi.Width = 50
 
+	sp := spinner.New()
+	sp.Spinner = spinner.Dot
+	sp.Style = selectedStyle
+
 	items := []list.Item{
 		item{title: "List Worktrees", desc: "View all existing worktrees"},
 		item{title: "Add Worktree", desc: "Create a new worktree"},
 		item{title: "Remove Worktree", desc: "Delete an existing worktree"},
+		item{title: "Clean up", desc: "Remove merged, missing and old worktrees"},
 		item{title: "Quit", desc: "Exit the application"},
 	}
 
@@ -101,15 +154,41 @@ func NewModel() Model {
 	l.SetFilteringEnabled(false)
 
 	return Model{
+		repo:            repo,
 		state:           menuView,
 		list:            l,
 		pathInput:       ti,
 		branchNameInput: bi,
+		spinner:         sp,
 	}
 }
 
+// NewSwitchModel returns a model that only shows the worktree picker and
+// quits as soon as a worktree is chosen (see JumpTarget)
+func NewSwitchModel(repo *git.Repository) Model {
+	m := NewModel(repo)
+	m.switchMode = true
+	m.state = listView
+	m.list.SetItems(nil)
+	m.list.Title = "Select worktree to switch to (ESC to cancel)"
+	m.initCmd = m.loadWorktrees(listView)
+	return m
+}
+
+// JumpTarget returns the directory the user chose to switch to or the
+// worktree that was created most recently, or "" if there is none
+func (m Model) JumpTarget() string {
+	return m.jumpTarget
+}
+
+// Jumped reports whether the user quit to switch to JumpTarget, rather
+// than it being the worktree created last
+func (m Model) Jumped() bool {
+	return m.jumped
+}
+
 func (m Model) Init() tea.Cmd {
-	return nil
+	return m.initCmd
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -120,10 +199,95 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.list.SetSize(msg.Width, msg.Height-4)
 		return m, nil
 
+	case spinner.TickMsg:
+		if !m.busy {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
+	case hookLogMsg:
+		if msg.id != m.opID {
+			return m, nil
+		}
+		m.appendHookLog(msg.line)
+		return m, waitForHookLog(msg.id, msg.logs)
+
+	case opDoneMsg:
+		if msg.id != m.opID {
+			// Cancelled or superseded operation
+			return m, nil
+		}
+		m.finishOp()
+		return m.handleResult(msg.result)
+
 	case tea.KeyMsg:
+		// While git is running only allow cancelling it
+		if m.busy {
+			switch msg.String() {
+			case "esc", "ctrl+c":
+				m.cancelOp()
+				if m.switchMode {
+					m.quitting = true
+					return m, tea.Quit
+				}
+			}
+			return m, nil
+		}
+
+		switch m.state {
+		case confirmRemoveView:
+			return m.handleConfirmRemoveKey(msg)
+		case confirmForceDeleteBranchView:
+			return m.handleConfirmForceDeleteBranchKey(msg)
+		case confirmBulkRemoveView:
+			return m.handleConfirmBulkRemoveKey(msg)
+		case bulkResultView:
+			return m.handleBulkResultKey(msg)
+		case hookLogView:
+			return m.handleHookLogKey(msg)
+		case checkedOutView:
+			return m.handleCheckedOutKey(msg)
+		case newBranchNameView:
+			if msg.String() == "tab" {
+				// Accept the sanitized name offered below the input
+				if slug, _ := m.checkBranchName(); slug != "" {
+					m.branchNameInput.SetValue(slug)
+					m.branchNameInput.CursorEnd()
+				}
+				return m, nil
+			}
+		case addView, newBranchBaseView:
+			// F refreshes the branches from the remotes unless it is being
+			// typed into the filter
+			if m.list.FilterState() != list.Filtering {
+				switch msg.String() {
+				case "F":
+					return m, m.fetchBranches(m.state)
+				case "s":
+					m.cycleBranchSort()
+					return m, nil
+				}
+			}
+		case removeView:
+			switch msg.String() {
+			case " ":
+				m.toggleMarked()
+				return m, nil
+			case "a":
+				m.markMerged()
+				return m, nil
+			}
+		}
+
 		switch msg.String() {
 		case "ctrl+c", "q":
-			if m.state == menuView {
+			if msg.String() == "q" && (m.state == newBranchNameView || m.state == customPathView || m.list.FilterState() == list.Filtering) {
+				// Typed into the input or the list filter, e.g. of the issue picker
+				break
+			}
+			if m.state == menuView || m.switchMode {
 				m.quitting = true
 				return m, tea.Quit
 			}
@@ -134,6 +298,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 
 		case "esc":
+			if m.switchMode {
+				m.quitting = true
+				return m, tea.Quit
+			}
 			if m.state != menuView {
 				m.state = menuView
 				m.err = nil
@@ -150,7 +318,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 
 	switch m.state {
-	case menuView, listView, branchModeSelectView, addView, newBranchBaseView, branchNameSuggestionView, removeView, pathSelectView:
+	case menuView, listView, branchModeSelectView, addView, newBranchBaseView, branchNameSuggestionView, issuePickerView, removeView, pathSelectView:
 		var cmd tea.Cmd
 		m.list, cmd = m.list.Update(msg)
 		return m, cmd
@@ -177,28 +345,7 @@ func (m Model) handleEnter() (tea.Model, tea.Cmd) {
 
 		switch selected.(item).title {
 		case "List Worktrees":
-			m.state = listView
-			worktrees, err := git.ListWorktrees()
-			if err != nil {
-				m.err = err
-				m.state = menuView
-				return m, nil
-			}
-			m.worktrees = worktrees
-
-			items := make([]list.Item, len(worktrees))
-			for i, wt := range worktrees {
-				branch := wt.Branch
-				if branch == "" {
-					branch = "detached"
-				}
-				items[i] = item{
-					title: wt.Path,
-					desc:  fmt.Sprintf("Branch: %s | Commit: %.7s", branch, wt.Commit),
-				}
-			}
-			m.list.SetItems(items)
-			m.list.Title = "Worktrees (press ESC to go back)"
+			return m, m.loadWorktrees(listView)
 
 		case "Add Worktree":
 			// Show branch mode selection
@@ -207,37 +354,15 @@ func (m Model) handleEnter() (tea.Model, tea.Cmd) {
 				item{title: "Create new branch", desc: "Create a new branch and worktree"},
 			}
 			m.list.SetItems(items)
+			m.list.ResetSelected()
 			m.list.Title = "Choose branch mode (press ESC to cancel)"
 			m.state = branchModeSelectView
 
 		case "Remove Worktree":
-			worktrees, err := git.ListWorktrees()
-			if err != nil {
-				m.err = err
-				return m, nil
-			}
-			// Filter out the main worktree (first one)
-			if len(worktrees) > 1 {
-				m.worktrees = worktrees[1:]
-			} else {
-				m.message = "No additional worktrees to remove"
-				return m, nil
-			}
+			return m, m.loadWorktrees(removeView)
 
-			items := make([]list.Item, len(m.worktrees))
-			for i, wt := range m.worktrees {
-				branch := wt.Branch
-				if branch == "" {
-					branch = "detached"
-				}
-				items[i] = item{
-					title: wt.Path,
-					desc:  fmt.Sprintf("Branch: %s", branch),
-				}
-			}
-			m.list.SetItems(items)
-			m.list.Title = "Select worktree to remove (press ESC to cancel)"
-			m.state = removeView
+		case "Clean up":
+			return m, m.findStaleWorktrees()
 
 		case "Quit":
 			m.quitting = true
@@ -245,8 +370,19 @@ func (m Model) handleEnter() (tea.Model, tea.Cmd) {
 		}
 
 	case listView:
-		// Just viewing, do nothing on Enter
-		return m, nil
+		if !m.switchMode {
+			// Just viewing, do nothing on Enter
+			return m, nil
+		}
+
+		selected := m.list.SelectedItem()
+		if selected == nil {
+			return m, nil
+		}
+		m.jumpTarget = selected.(item).title
+		m.jumped = true
+		m.quitting = true
+		return m, tea.Quit
 
 	case branchModeSelectView:
 		selected := m.list.SelectedItem()
@@ -257,41 +393,11 @@ func (m Model) handleEnter() (tea.Model, tea.Cmd) {
 		switch selected.(item).title {
 		case "Use existing branch":
 			m.isNewBranch = false
-			branches, err := git.ListBranches()
-			if err != nil {
-				m.err = err
-				m.state = menuView
-				return m, nil
-			}
-			m.branches = branches
-
-			items := make([]list.Item, len(branches))
-			for i, branch := range branches {
-				items[i] = item{title: branch, desc: ""}
-			}
-			m.list.SetItems(items)
-			m.list.SetFilteringEnabled(true)
-			m.list.Title = "Select an existing branch (type to filter, ESC to cancel)"
-			m.state = addView
+			return m, m.listBranches(addView)
 
 		case "Create new branch":
 			m.isNewBranch = true
-			branches, err := git.ListBranches()
-			if err != nil {
-				m.err = err
-				m.state = menuView
-				return m, nil
-			}
-			m.branches = branches
-
-			items := make([]list.Item, len(branches))
-			for i, branch := range branches {
-				items[i] = item{title: branch, desc: "Base branch for new branch"}
-			}
-			m.list.SetItems(items)
-			m.list.SetFilteringEnabled(true)
-			m.list.Title = "Select base branch (type to filter, ESC to cancel)"
-			m.state = newBranchBaseView
+			return m, m.listBranches(newBranchBaseView)
 		}
 
 	case newBranchBaseView:
@@ -300,34 +406,10 @@ func (m Model) handleEnter() (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
-		m.baseBranch = selected.(item).title
+		m.baseBranch = selected.(item).value
 
 		// Get branch name suggestions
-		suggestions, err := git.SuggestBranchNames()
-		if err != nil {
-			m.err = err
-			m.state = menuView
-			m.resetMenuItems()
-			return m, nil
-		}
-		m.branchNameSuggestions = suggestions
-
-		// Show branch name suggestion screen
-		items := make([]list.Item, len(suggestions))
-		for i, sug := range suggestions {
-			title := sug.Name
-			if sug.IsCustom {
-				title = "✏️  Custom name..."
-			}
-			items[i] = item{
-				title: title,
-				desc:  sug.Description,
-			}
-		}
-		m.list.SetItems(items)
-		m.list.SetFilteringEnabled(false)
-		m.list.Title = "Select branch name pattern (ESC to cancel)"
-		m.state = branchNameSuggestionView
+		return m, m.suggestBranchNames()
 
 	case branchNameSuggestionView:
 		selected := m.list.SelectedItem()
@@ -344,6 +426,16 @@ func (m Model) handleEnter() (tea.Model, tea.Cmd) {
 			}
 		}
 
+		// Branch name providers are listed after the suggestions
+		providers := m.repo.BranchNameProviders()
+		if i := selectedIndex - len(m.branchNameSuggestions); i >= 0 && i < len(providers) {
+			prefix := ""
+			if len(m.branchNameSuggestions) > 0 && !m.branchNameSuggestions[0].IsCustom {
+				prefix = m.branchNameSuggestions[0].Name
+			}
+			return m, m.loadProviderBranchNames(providers[i], prefix)
+		}
+
 		if selectedIndex < 0 || selectedIndex >= len(m.branchNameSuggestions) {
 			return m, nil
 		}
@@ -356,48 +448,30 @@ func (m Model) handleEnter() (tea.Model, tea.Cmd) {
 		m.branchNameInput.Focus()
 		m.state = newBranchNameView
 
+	case issuePickerView:
+		selected := m.list.SelectedItem()
+		if selected == nil {
+			return m, nil
+		}
+
+		// Let the name be reviewed and edited before it is used
+		m.selectedPrefix = ""
+		m.branchNameInput.SetValue(selected.(item).value)
+		m.branchNameInput.CursorEnd()
+		m.branchNameInput.Focus()
+		m.state = newBranchNameView
+
 	case newBranchNameView:
 		newBranchName := m.branchNameInput.Value()
-		if newBranchName == "" {
-			m.err = fmt.Errorf("branch name cannot be empty")
-			m.state = menuView
-			m.resetMenuItems()
+		if _, err := m.checkBranchName(); err != nil {
+			// The problem is already shown below the input
 			return m, nil
 		}
 
 		m.selectedBranch = newBranchName
 
 		// Get path suggestions based on new branch name
-		suggestions, err := git.SuggestPaths(newBranchName)
-		if err != nil {
-			m.err = err
-			m.state = menuView
-			m.resetMenuItems()
-			return m, nil
-		}
-		m.pathSuggestions = suggestions
-
-		// Show path selection screen
-		items := make([]list.Item, len(suggestions))
-		for i, sug := range suggestions {
-			title := sug.Path
-			desc := sug.Description
-			if sug.IsCustom {
-				title = "✏️  Custom path..."
-			} else {
-				// Add full path to description
-				if absPath, err := filepath.Abs(sug.Path); err == nil {
-					desc = fmt.Sprintf("%s → %s", sug.Description, absPath)
-				}
-			}
-			items[i] = item{
-				title: title,
-				desc:  desc,
-			}
-		}
-		m.list.SetItems(items)
-		m.list.Title = fmt.Sprintf("Select path for new branch '%s' (ESC to cancel)", newBranchName)
-		m.state = pathSelectView
+		return m, m.suggestPaths(newBranchName)
 
 	case addView:
 		selected := m.list.SelectedItem()
@@ -405,39 +479,29 @@ func (m Model) handleEnter() (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
-		branch := selected.(item).title
-		m.selectedBranch = branch
-
-		// Get path suggestions based on branch
-		suggestions, err := git.SuggestPaths(branch)
-		if err != nil {
-			m.err = err
-			m.state = menuView
-			return m, nil
-		}
-		m.pathSuggestions = suggestions
-
-		// Show path selection screen
-		items := make([]list.Item, len(suggestions))
-		for i, sug := range suggestions {
-			title := sug.Path
-			desc := sug.Description
-			if sug.IsCustom {
-				title = "✏️  Custom path..."
-			} else {
-				// Add full path to description
-				if absPath, err := filepath.Abs(sug.Path); err == nil {
-					desc = fmt.Sprintf("%s → %s", sug.Description, absPath)
-				}
+		ref := selected.(item).value
+		m.selectedBranch = ref
+		m.trackRef = ""
+		m.detach = false
+		for _, b := range m.branches {
+			if b.Ref() != ref {
+				continue
 			}
-			items[i] = item{
-				title: title,
-				desc:  desc,
+			if !b.IsLocal {
+				// Picking a remote-only branch creates a local tracking branch
+				m.selectedBranch = b.Name
+				m.trackRef = ref
+			}
+			if b.CheckedOut != "" {
+				// git refuses to check a branch out twice
+				m.checkedOutAt = b.CheckedOut
+				m.state = checkedOutView
+				return m, nil
 			}
 		}
-		m.list.SetItems(items)
-		m.list.Title = fmt.Sprintf("Select path for '%s' (ESC to cancel)", branch)
-		m.state = pathSelectView
+
+		// Get path suggestions based on branch
+		return m, m.suggestPaths(m.selectedBranch)
 
 	case pathSelectView:
 		selected := m.list.SelectedItem()
@@ -469,70 +533,392 @@ func (m Model) handleEnter() (tea.Model, tea.Cmd) {
 		}
 
 		// Otherwise, use the suggested path
-		var err error
-		if m.isNewBranch {
-			// Create worktree with new branch
-			err = git.AddWorktreeWithNewBranch(suggestion.Path, m.selectedBranch, m.baseBranch)
-			if err != nil {
-				m.err = err
+		return m, m.addWorktree(suggestion.Path, suggestion.Template)
+
+	case customPathView:
+		path := m.pathInput.Value()
+		if path == "" {
+			m.err = fmt.Errorf("path cannot be empty")
+			m.state = menuView
+			m.resetMenuItems()
+			return m, nil
+		}
+
+		return m, m.addWorktree(path, m.repo.PathTemplate(path, m.selectedBranch))
+
+	case removeView:
+		if len(m.marked) > 0 {
+			return m, m.preflightBulkRemoval(m.markedWorktrees())
+		}
+
+		selected := m.list.SelectedItem()
+		if selected == nil {
+			return m, nil
+		}
+
+		path := selected.(item).value
+		for _, wt := range m.worktrees {
+			if wt.Path == path {
+				return m, m.preflightRemoval(wt)
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// handleConfirmRemoveKey handles the removal confirmation dialog. Force
+// removal is only offered when git would otherwise refuse.
+func (m Model) handleConfirmRemoveKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y":
+		if !m.preflight.NeedsForce() {
+			m.forceRemove = false
+			return m.confirmRemoval()
+		}
+	case "f":
+		if m.preflight.NeedsForce() {
+			m.forceRemove = true
+			return m.confirmRemoval()
+		}
+	case "b":
+		if m.preflight.Worktree.Branch != "" {
+			m.deleteBranch = !m.deleteBranch
+			if !m.deleteBranch {
+				m.deleteRemote = false
+			}
+		}
+	case "r":
+		if m.deleteBranch && m.preflight.Remote != "" {
+			m.deleteRemote = !m.deleteRemote
+		}
+	case "n", "esc", "q", "ctrl+c":
+		return m.cancelRemoval()
+	}
+	return m, nil
+}
+
+// confirmRemoval starts the removal, first asking again if an unmerged
+// branch would have to be force-deleted
+func (m Model) confirmRemoval() (tea.Model, tea.Cmd) {
+	if m.deleteBranch && !m.preflight.BranchMerged {
+		m.state = confirmForceDeleteBranchView
+		return m, nil
+	}
+
+	deletion := git.KeepBranch
+	if m.deleteBranch {
+		deletion = git.DeleteMergedBranch
+	}
+	return m, m.removeWorktree(m.preflight.Worktree, m.removeOptions(deletion))
+}
+
+// handleConfirmForceDeleteBranchKey handles the second confirmation needed
+// to delete an unmerged branch with 'git branch -D'
+func (m Model) handleConfirmForceDeleteBranchKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "D":
+		return m, m.removeWorktree(m.preflight.Worktree, m.removeOptions(git.ForceDeleteBranch))
+	case "k":
+		m.deleteRemote = false
+		return m, m.removeWorktree(m.preflight.Worktree, m.removeOptions(git.KeepBranch))
+	case "n", "esc", "q", "ctrl+c":
+		return m.cancelRemoval()
+	}
+	return m, nil
+}
+
+func (m Model) removeOptions(deletion git.BranchDeletion) git.RemoveOptions {
+	return git.RemoveOptions{
+		Force:        m.forceRemove,
+		Branch:       m.preflight.Worktree.Branch,
+		DeleteBranch: deletion,
+		DeleteRemote: deletion != git.KeepBranch && m.deleteRemote,
+	}
+}
+
+func (m Model) cancelRemoval() (tea.Model, tea.Cmd) {
+	m.state = menuView
+	m.message = "Removal cancelled"
+	m.resetMenuItems()
+	return m, nil
+}
+
+// handleResult applies the result of a finished async git operation
+func (m Model) handleResult(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case worktreesLoadedMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			if !m.switchMode {
+				m.state = menuView
+				m.resetMenuItems()
+			}
+			return m, nil
+		}
+
+		switch msg.target {
+		case listView:
+			m.showWorktreeList(msg.worktrees, msg.statuses, msg.statusErrs)
+			if !m.switchMode {
+				m.list.Title = "Worktrees (press ESC to go back)"
+			}
+			m.state = listView
+
+		case removeView:
+			// Filter out the main worktree (first one)
+			if len(msg.worktrees) > 1 {
+				m.worktrees = msg.worktrees[1:]
 			} else {
-				m.message = fmt.Sprintf("Successfully created branch '%s' and worktree at %s", m.selectedBranch, suggestion.Path)
+				m.message = "No additional worktrees to remove"
+				return m, nil
 			}
+
+			m.cleanup = false
+			m.staleReasons = nil
+			m.marked = make(map[string]bool)
+			m.mergedBranches = msg.merged
+			m.setRemoveItems()
+			m.list.ResetSelected()
+			m.list.Title = "Select worktree to remove (space to mark, a to mark merged, ESC to cancel)"
+			m.state = removeView
+		}
+
+	case branchesLoadedMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			m.state = menuView
+			m.resetMenuItems()
+			return m, nil
+		}
+		m.branches = msg.branches
+		m.userEmail = msg.email
+		m.err = msg.fetchErr
+		m.setBranchItems(msg.target)
+		m.list.ResetSelected()
+		m.list.SetFilteringEnabled(true)
+		if msg.target == newBranchBaseView {
+			m.list.Title = "Select base branch (type to filter, ESC to cancel)"
 		} else {
-			// Use existing branch
-			err = git.AddWorktree(suggestion.Path, m.selectedBranch)
-			if err != nil {
-				m.err = err
-			} else {
-				m.message = fmt.Sprintf("Successfully added worktree at %s", suggestion.Path)
+			m.list.Title = "Select an existing branch (type to filter, ESC to cancel)"
+		}
+		m.state = msg.target
+
+	case branchNamesSuggestedMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			m.state = menuView
+			m.resetMenuItems()
+			return m, nil
+		}
+		m.branchNameSuggestions = msg.suggestions
+
+		// Show branch name suggestion screen
+		items := make([]list.Item, len(msg.suggestions))
+		for i, sug := range msg.suggestions {
+			title := sug.Name
+			if sug.IsCustom {
+				title = "✏️  Custom name..."
+			}
+			items[i] = item{
+				title: title,
+				desc:  sug.Description,
 			}
 		}
-		m.state = menuView
-		m.resetMenuItems()
+		for _, p := range m.repo.BranchNameProviders() {
+			items = append(items, item{
+				title: fmt.Sprintf("🎫 From %s...", p.Name()),
+				desc:  "Name the branch after an issue",
+			})
+		}
+		m.list.SetItems(items)
+		m.list.ResetSelected()
+		m.list.SetFilteringEnabled(false)
+		m.list.Title = "Select branch name pattern (ESC to cancel)"
+		m.state = branchNameSuggestionView
 
-	case customPathView:
-		path := m.pathInput.Value()
-		if path == "" {
-			m.err = fmt.Errorf("path cannot be empty")
+	case providerBranchNamesMsg:
+		if msg.err == nil && len(msg.suggestions) == 0 {
+			msg.err = fmt.Errorf("no issues found in %s", msg.source)
+		}
+		if msg.err != nil {
+			// Stay on the patterns so another one can be picked
+			m.err = msg.err
+			return m, nil
+		}
+
+		items := make([]list.Item, len(msg.suggestions))
+		for i, sug := range msg.suggestions {
+			items[i] = item{
+				title: sug.Description,
+				desc:  sug.Name,
+				value: sug.Name,
+			}
+		}
+		m.list.SetItems(items)
+		m.list.ResetSelected()
+		m.list.SetFilteringEnabled(true)
+		m.list.Title = fmt.Sprintf("Select from %s (type to filter, ESC to cancel)", msg.source)
+		m.state = issuePickerView
+
+	case pathsSuggestedMsg:
+		if msg.err != nil {
+			m.err = msg.err
 			m.state = menuView
 			m.resetMenuItems()
 			return m, nil
 		}
+		m.pathSuggestions = msg.suggestions
+		m.filePlan = msg.files
 
-		var err error
-		if m.isNewBranch {
-			// Create worktree with new branch
-			err = git.AddWorktreeWithNewBranch(path, m.selectedBranch, m.baseBranch)
-			if err != nil {
-				m.err = err
+		// Show path selection screen
+		items := make([]list.Item, len(msg.suggestions))
+		firstFree := -1
+		for i, sug := range msg.suggestions {
+			title := sug.Path
+			desc := sug.Description
+			if sug.IsCustom {
+				title = "✏️  Custom path..."
 			} else {
-				m.message = fmt.Sprintf("Successfully created branch '%s' and worktree at %s", m.selectedBranch, path)
+				if sug.Warning != "" {
+					title = "⚠️  " + title
+					desc = fmt.Sprintf("%s, but %s", desc, sug.Warning)
+				} else if firstFree < 0 {
+					firstFree = i
+				}
+				if sug.NewDir != "" {
+					desc = fmt.Sprintf("%s, creates directory %s", desc, sug.NewDir)
+				}
+				// Add full path to description
+				if absPath, err := m.repo.AbsPath(sug.Path); err == nil {
+					desc = fmt.Sprintf("%s → %s", desc, absPath)
+				}
+			}
+			items[i] = item{
+				title: title,
+				desc:  desc,
 			}
+		}
+		m.list.SetItems(items)
+		m.list.ResetSelected()
+		if firstFree > 0 {
+			// Start on a path that works rather than a colliding one
+			m.list.Select(firstFree)
+		}
+		m.list.SetFilteringEnabled(false)
+		if m.isNewBranch {
+			m.list.Title = fmt.Sprintf("Select path for new branch '%s' (ESC to cancel)", msg.branch)
 		} else {
-			// Use existing branch
-			err = git.AddWorktree(path, m.selectedBranch)
-			if err != nil {
-				m.err = err
+			m.list.Title = fmt.Sprintf("Select path for '%s' (ESC to cancel)", msg.branch)
+		}
+		m.state = pathSelectView
+
+	case worktreeAddedMsg:
+		if msg.err != nil {
+			m.err = msg.err
+		} else {
+			if m.isNewBranch {
+				m.message = fmt.Sprintf("Successfully created branch '%s' and worktree at %s", m.selectedBranch, msg.path)
+			} else if m.detach {
+				m.message = fmt.Sprintf("Successfully added detached worktree at %s (at '%s')", msg.path, m.selectedBranch)
+			} else if m.trackRef != "" {
+				m.message = fmt.Sprintf("Successfully created branch '%s' tracking '%s' and worktree at %s", m.selectedBranch, m.trackRef, msg.path)
 			} else {
-				m.message = fmt.Sprintf("Successfully added worktree at %s", path)
+				m.message = fmt.Sprintf("Successfully added worktree at %s", msg.path)
+			}
+			m.jumpTarget = m.absPath(msg.path)
+			if msg.setupErr != nil {
+				// The worktree is usable; only its bootstrapping failed
+				m.err = fmt.Errorf("worktree was created at %s, but %w", msg.path, msg.setupErr)
 			}
 		}
 		m.pathInput.SetValue("")
+		if msg.showLog {
+			m.state = hookLogView
+			return m, nil
+		}
 		m.state = menuView
 		m.resetMenuItems()
 
-	case removeView:
-		selected := m.list.SelectedItem()
-		if selected == nil {
+	case staleWorktreesMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			m.state = menuView
+			m.resetMenuItems()
+			return m, nil
+		}
+		m.message = ""
+		if msg.mergedInto == "" {
+			m.message = "No default branch found, so merged worktrees were not looked for; set default_base in the config"
+		}
+		if len(msg.stale) == 0 {
+			if m.message != "" {
+				m.message = "No stale worktrees found. " + m.message
+			} else {
+				m.message = "No stale worktrees found"
+			}
 			return m, nil
 		}
 
-		path := selected.(item).title
-		err := git.RemoveWorktree(path)
-		if err != nil {
-			m.err = err
+		// Reuse the remove list with every candidate marked
+		m.cleanup = true
+		m.worktrees = nil
+		m.marked = make(map[string]bool)
+		m.mergedBranches = nil
+		m.staleReasons = make(map[string]string)
+		for _, s := range msg.stale {
+			m.worktrees = append(m.worktrees, s.Worktree)
+			m.marked[s.Worktree.Path] = true
+			m.staleReasons[s.Worktree.Path] = strings.Join(s.Reasons, ", ")
+		}
+		m.setRemoveItems()
+		m.list.ResetSelected()
+		m.list.Title = "Clean up stale worktrees (space to unmark, Enter to review, ESC to cancel)"
+		m.state = removeView
+
+	case bulkPreflightMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			m.state = menuView
+			m.resetMenuItems()
+			return m, nil
+		}
+		m.bulkPreflights = msg.preflights
+		m.state = confirmBulkRemoveView
+
+	case bulkRemovedMsg:
+		m.err = msg.err
+		m.bulkResults = msg.results
+		m.state = bulkResultView
+
+	case removalPreflightMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			m.state = menuView
+			m.resetMenuItems()
+			return m, nil
+		}
+		m.preflight = msg.preflight
+		m.forceRemove = false
+		m.deleteBranch = false
+		m.deleteRemote = false
+		m.state = confirmRemoveView
+
+	case worktreeRemovedMsg:
+		if msg.err != nil {
+			m.err = msg.err
 		} else {
-			m.message = fmt.Sprintf("Successfully removed worktree at %s", path)
+			m.message = fmt.Sprintf("Successfully removed worktree at %s", msg.path)
+			if msg.opts.DeleteBranch != git.KeepBranch {
+				m.message += fmt.Sprintf(" and branch '%s'", msg.opts.Branch)
+			}
+			if msg.opts.DeleteRemote {
+				m.message += fmt.Sprintf(" (including %s/%s)", m.preflight.Remote, m.preflight.RemoteBranch)
+			}
+		}
+		if msg.hooksRan {
+			m.state = hookLogView
+			return m, nil
 		}
 		m.state = menuView
 		m.resetMenuItems()
@@ -541,14 +927,103 @@ func (m Model) handleEnter() (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// absPath resolves a worktree path relative to the repository, falling
+// back to the path as given
+func (m Model) absPath(path string) string {
+	if abs, err := m.repo.AbsPath(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// showWorktreeList puts worktrees and their status into the list. Bare
+// worktrees are skipped in switch mode since they have no working
+// directory to enter.
+func (m *Model) showWorktreeList(worktrees []git.Worktree, statuses map[string]git.WorktreeStatus, statusErrs map[string]error) {
+	m.worktrees = nil
+	for _, wt := range worktrees {
+		if m.switchMode && wt.IsBare {
+			continue
+		}
+		m.worktrees = append(m.worktrees, wt)
+	}
+
+	now := time.Now()
+	items := make([]list.Item, len(m.worktrees))
+	for i, wt := range m.worktrees {
+		branch := wt.Branch
+		if branch == "" {
+			branch = "detached"
+		}
+		desc := withAnnotations(fmt.Sprintf("Branch: %s | Commit: %.7s", branch, wt.Commit), wt)
+		if status, ok := statuses[wt.Path]; ok {
+			desc = fmt.Sprintf("%s | %s", desc, statusSummary(status, now))
+		} else if err, ok := statusErrs[wt.Path]; ok {
+			desc = fmt.Sprintf("%s | status unavailable: %v", desc, err)
+		}
+		items[i] = item{
+			title: wt.Path,
+			desc:  desc,
+		}
+	}
+	m.list.SetItems(items)
+	m.list.ResetSelected()
+}
+
+// statusSummary renders a compact dashboard line such as
+// "+2 ~1 ?3 | ↑1 ↓0 | Fix parser (alice, 3 days ago)"
+func statusSummary(status git.WorktreeStatus, now time.Time) string {
+	var changes []string
+	if status.Staged > 0 {
+		changes = append(changes, fmt.Sprintf("+%d", status.Staged))
+	}
+	if status.Unstaged > 0 {
+		changes = append(changes, fmt.Sprintf("~%d", status.Unstaged))
+	}
+	if status.Untracked > 0 {
+		changes = append(changes, fmt.Sprintf("?%d", status.Untracked))
+	}
+	if status.Conflicted > 0 {
+		changes = append(changes, fmt.Sprintf("!%d", status.Conflicted))
+	}
+	parts := []string{"clean"}
+	if len(changes) > 0 {
+		parts[0] = strings.Join(changes, " ")
+	}
+
+	if status.Upstream != "" {
+		parts = append(parts, fmt.Sprintf("↑%d ↓%d", status.Ahead, status.Behind))
+	} else {
+		parts = append(parts, "no upstream")
+	}
+
+	if status.LastCommitSubject != "" {
+		parts = append(parts, fmt.Sprintf("%s (%s, %s)",
+			status.LastCommitSubject, status.LastCommitAuthor, git.RelativeAge(status.LastCommitTime, now)))
+	}
+
+	return strings.Join(parts, " | ")
+}
+
+// withAnnotations appends the worktree's special states (locked, prunable, ...) to desc
+func withAnnotations(desc string, wt git.Worktree) string {
+	labels := wt.Annotations()
+	if len(labels) == 0 {
+		return desc
+	}
+	return fmt.Sprintf("%s | %s", desc, strings.Join(labels, " | "))
+}
+
 func (m *Model) resetMenuItems() {
 	items := []list.Item{
 		item{title: "List Worktrees", desc: "View all existing worktrees"},
 		item{title: "Add Worktree", desc: "Create a new worktree"},
 		item{title: "Remove Worktree", desc: "Delete an existing worktree"},
+		item{title: "Clean up", desc: "Remove merged, missing and old worktrees"},
 		item{title: "Quit", desc: "Exit the application"},
 	}
 	m.list.SetItems(items)
+	m.list.ResetSelected()
 	m.list.SetFilteringEnabled(false)
 	m.list.Title = "Git Worktree Manager"
 }
@@ -562,18 +1037,44 @@ func (m Model) View() string {
 
 	// Show error or success message
 	if m.err != nil {
-		s.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v\n\n", m.err)))
+		// Render the blank lines outside the style so the padding does not
+		// push the next line to the right; wrap long errors to the window
+		style := errorStyle
+		if m.width > 0 {
+			style = style.Width(m.width)
+		}
+		s.WriteString(style.Render(fmt.Sprintf("Error: %v", m.err)) + "\n\n")
 	} else if m.message != "" {
-		s.WriteString(successStyle.Render(m.message + "\n\n"))
+		s.WriteString(successStyle.Render(m.message) + "\n\n")
+	}
+
+	// A running git operation replaces the current view until it finishes
+	if m.busy {
+		s.WriteString(fmt.Sprintf("%s %s", m.spinner.View(), m.busyLabel))
+		s.WriteString("\n\n")
+		if len(m.hookLog) > 0 {
+			s.WriteString(m.hookLogPane())
+			s.WriteString("\n\n")
+		}
+		s.WriteString("Press ESC to cancel")
+		return s.String()
 	}
 
 	switch m.state {
 	case menuView, listView, removeView:
 		s.WriteString(m.list.View())
+		if m.state == removeView {
+			s.WriteString("\n\n")
+			s.WriteString(fmt.Sprintf("%d marked | space: mark, a: mark merged, Enter: remove", len(m.marked)))
+		}
 		if m.state == menuView {
 			s.WriteString("\n\n")
 			s.WriteString("Use ↑/↓ to navigate, Enter to select, q to quit")
 		}
+		if m.switchMode {
+			s.WriteString("\n\n")
+			s.WriteString("Press Enter to switch, ESC to cancel")
+		}
 	case branchModeSelectView:
 		s.WriteString(m.list.View())
 		s.WriteString("\n\n")
@@ -581,28 +1082,66 @@ func (m Model) View() string {
 	case addView:
 		s.WriteString(m.list.View())
 		s.WriteString("\n\n")
+		s.WriteString(fmt.Sprintf("Sorted by %s | s: sort, F: fetch remotes\n", m.branchSort))
 		s.WriteString("Press Enter to select branch, ESC to cancel")
 	case newBranchBaseView:
 		s.WriteString(m.list.View())
 		s.WriteString("\n\n")
+		s.WriteString(fmt.Sprintf("Sorted by %s | s: sort, F: fetch remotes\n", m.branchSort))
 		s.WriteString("Select base branch for new branch, ESC to cancel")
 	case branchNameSuggestionView:
 		s.WriteString(m.list.View())
 		s.WriteString("\n\n")
 		s.WriteString("💡 Patterns learned from existing branches\n")
 		s.WriteString("Press Enter to select, ESC to cancel")
+	case issuePickerView:
+		s.WriteString(m.list.View())
+		s.WriteString("\n\n")
+		s.WriteString("Press Enter to use the branch name (editable next), ESC to cancel")
 	case newBranchNameView:
 		s.WriteString(titleStyle.Render(fmt.Sprintf("Create new branch from '%s'", m.baseBranch)))
 		s.WriteString("\n\n")
 		s.WriteString("Enter branch name:\n")
 		s.WriteString(m.branchNameInput.View())
+		s.WriteString("\n")
+		slug, err := m.checkBranchName()
+		switch {
+		case err == nil:
+			s.WriteString(successStyle.Render("✓ Valid branch name"))
+		case m.branchNameInput.Value() == m.selectedPrefix:
+			s.WriteString("Type the rest of the branch name")
+		default:
+			s.WriteString(errorStyle.Render("✗ " + err.Error()))
+			if slug != "" {
+				s.WriteString(fmt.Sprintf("\nPress Tab to use '%s'", slug))
+			}
+		}
 		s.WriteString("\n\n")
 		s.WriteString("Press Enter to confirm, ESC to cancel")
 	case pathSelectView:
 		s.WriteString(m.list.View())
 		s.WriteString("\n\n")
 		s.WriteString("💡 Suggestions are learned from your existing worktrees\n")
+		if summary := m.filePlan.Summary(); summary != "" {
+			s.WriteString(fmt.Sprintf("📄 From the main worktree: %s\n", summary))
+		}
 		s.WriteString("Press Enter to select, ESC to cancel")
+	case confirmRemoveView:
+		s.WriteString(m.confirmRemoveDialog())
+	case confirmForceDeleteBranchView:
+		s.WriteString(m.confirmForceDeleteBranchDialog())
+	case confirmBulkRemoveView:
+		s.WriteString(m.confirmBulkRemoveDialog())
+	case bulkResultView:
+		s.WriteString(m.bulkResultSummary())
+	case checkedOutView:
+		s.WriteString(m.checkedOutDialog())
+	case hookLogView:
+		s.WriteString(titleStyle.Render("Output"))
+		s.WriteString("\n\n")
+		s.WriteString(m.hookLogPane())
+		s.WriteString("\n\n")
+		s.WriteString("Press Enter or ESC to return to the menu")
 	case customPathView:
 		s.WriteString(titleStyle.Render(fmt.Sprintf("Custom path for '%s'", m.selectedBranch)))
 		s.WriteString("\n\n")
@@ -614,3 +1153,114 @@ func (m Model) View() string {
 
 	return s.String()
 }
+
+// confirmRemoveDialog renders the preflight findings and the available choices
+func (m Model) confirmRemoveDialog() string {
+	wt := m.preflight.Worktree
+	branch := wt.Branch
+	if branch == "" {
+		branch = "detached"
+	}
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Remove worktree?"))
+	b.WriteString("\n\n")
+	b.WriteString(fmt.Sprintf("%s\nBranch: %s", wt.Path, branch))
+	b.WriteString("\n\n")
+
+	risks := m.preflight.Risks()
+	if len(risks) == 0 {
+		b.WriteString(successStyle.Render("✓ No uncommitted changes, unpushed commits or stashes"))
+		b.WriteString("\n")
+	}
+	for _, risk := range risks {
+		b.WriteString(warningStyle.Render("⚠ " + risk))
+		b.WriteString("\n")
+	}
+	for _, stash := range m.preflight.Stashes {
+		b.WriteString("    " + stash + "\n")
+	}
+	b.WriteString("\n")
+
+	if wt.Branch != "" {
+		b.WriteString(fmt.Sprintf("%s Also delete branch '%s' (b)", checkbox(m.deleteBranch), wt.Branch))
+		if !m.preflight.BranchMerged {
+			b.WriteString(warningStyle.Render(" — not fully merged"))
+		}
+		b.WriteString("\n")
+		if m.preflight.Remote != "" {
+			b.WriteString(fmt.Sprintf("%s Also delete remote branch '%s/%s' (r)",
+				checkbox(m.deleteRemote), m.preflight.Remote, m.preflight.RemoteBranch))
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
+	if m.preflight.NeedsForce() {
+		b.WriteString("git refuses to remove this worktree without --force.\n")
+		b.WriteString("Press f to force remove and discard the above, n or ESC to cancel")
+	} else {
+		b.WriteString("Press y to remove, n or ESC to cancel")
+	}
+
+	return modalStyle.Render(b.String())
+}
+
+// confirmForceDeleteBranchDialog asks before deleting an unmerged branch
+func (m Model) confirmForceDeleteBranchDialog() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Delete unmerged branch?"))
+	b.WriteString("\n\n")
+	b.WriteString(warningStyle.Render(fmt.Sprintf("⚠ Branch '%s' is not fully merged.", m.preflight.Worktree.Branch)))
+	b.WriteString("\n")
+	b.WriteString("Commits that exist only on this branch will be lost (git branch -D).")
+	b.WriteString("\n\n")
+	b.WriteString("Press D to delete it anyway, k to keep the branch and only remove the worktree,\n")
+	b.WriteString("n or ESC to cancel")
+	return modalStyle.Render(b.String())
+}
+
+// handleCheckedOutKey handles the choice for a branch that is already
+// checked out in another worktree
+func (m Model) handleCheckedOutKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "j":
+		m.jumpTarget = m.checkedOutAt
+		m.jumped = true
+		m.quitting = true
+		return m, tea.Quit
+	case "d":
+		m.detach = true
+		// Keep the suggestion apart from the worktree that has the branch
+		return m, m.suggestPaths(m.selectedBranch + "-detached")
+	case "n", "esc", "q", "ctrl+c":
+		m.state = addView
+	}
+	return m, nil
+}
+
+// checkedOutDialog explains why the branch cannot be checked out again
+func (m Model) checkedOutDialog() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Branch already checked out"))
+	b.WriteString("\n\n")
+	b.WriteString(fmt.Sprintf("'%s' is checked out at\n%s", m.selectedBranch, m.checkedOutAt))
+	b.WriteString("\n\n")
+	b.WriteString("git allows a branch in only one worktree at a time.\n\n")
+	if shell.Wrapped() {
+		b.WriteString("Press j to jump to that worktree and quit,\n")
+	} else {
+		// Without the wrapper rtr cannot change the shell's directory
+		b.WriteString("Press j to quit and print the path of that worktree,\n")
+	}
+	b.WriteString("d to add a worktree with a detached HEAD at its commit,\n")
+	b.WriteString("n or ESC to pick another branch")
+	return modalStyle.Render(b.String())
+}
+
+func checkbox(checked bool) string {
+	if checked {
+		return "[x]"
+	}
+	return "[ ]"
+}
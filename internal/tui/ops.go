@@ -0,0 +1,417 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/FScoward/rakutree/internal/files"
+	"github.com/FScoward/rakutree/internal/git"
+	"github.com/FScoward/rakutree/internal/hooks"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// opDoneMsg delivers the result of an async git operation. Results whose
+// id no longer matches the model's current operation are stale (the
+// operation was cancelled or superseded) and are dropped.
+type opDoneMsg struct {
+	id     int
+	result tea.Msg
+}
+
+// worktreesLoadedMsg is sent when worktrees have been listed for a view
+type worktreesLoadedMsg struct {
+	target     viewState
+	worktrees  []git.Worktree
+	statuses   map[string]git.WorktreeStatus
+	statusErrs map[string]error
+	merged     map[string]bool
+	err        error
+}
+
+// branchesLoadedMsg is sent when branches have been listed for a view
+type branchesLoadedMsg struct {
+	target   viewState
+	branches []git.Branch
+	email    string // The user's email, to sort their branches first
+	fetchErr error  // Fetching a remote failed; the branches are what is known locally
+	err      error
+}
+
+// branchNamesSuggestedMsg is sent when branch name suggestions are ready
+type branchNamesSuggestedMsg struct {
+	suggestions []git.BranchNameSuggestion
+	err         error
+}
+
+// providerBranchNamesMsg is sent when a branch name provider has answered
+type providerBranchNamesMsg struct {
+	source      string
+	suggestions []git.BranchNameSuggestion
+	err         error
+}
+
+// pathsSuggestedMsg is sent when path suggestions for branch are ready
+type pathsSuggestedMsg struct {
+	branch      string
+	suggestions []git.PathSuggestion
+	files       files.Plan
+	err         error
+}
+
+// worktreeAddedMsg is sent when a worktree has been created (or failed to
+// be) and its post-create hooks have run
+type worktreeAddedMsg struct {
+	path     string
+	err      error
+	showLog  bool  // Files were brought in or hooks ran, so there is output to show
+	setupErr error // Bringing in files or a hook failed; the worktree exists
+}
+
+// removalPreflightMsg is sent when the pre-removal checks have finished
+type removalPreflightMsg struct {
+	preflight git.RemovalPreflight
+	err       error
+}
+
+// staleWorktreesMsg is sent when cleanup candidates have been found
+type staleWorktreesMsg struct {
+	stale      []git.StaleWorktree
+	mergedInto string // Empty if merged worktrees were not looked for
+	err        error
+}
+
+// bulkPreflightMsg is sent when the pre-removal checks for all marked
+// worktrees have finished
+type bulkPreflightMsg struct {
+	preflights []git.RemovalPreflight
+	err        error
+}
+
+// bulkRemovedMsg is sent when a batch removal has finished
+type bulkRemovedMsg struct {
+	results []bulkRemoveResult
+	err     error
+}
+
+// bulkRemoveResult is the outcome of removing one worktree of a batch
+type bulkRemoveResult struct {
+	path    string
+	err     error
+	skipped string // Why the worktree was not attempted; empty if it was
+}
+
+// worktreeRemovedMsg is sent when a worktree has been removed (or failed to be)
+type worktreeRemovedMsg struct {
+	path     string
+	opts     git.RemoveOptions
+	err      error
+	hooksRan bool
+}
+
+// startOp runs fn in the background, cancelling any operation already in
+// flight. The spinner is shown until the result arrives or the user cancels.
+func (m *Model) startOp(label string, fn func(ctx context.Context) tea.Msg) tea.Cmd {
+	if m.cancel != nil {
+		m.cancel()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.opID++
+	id := m.opID
+	m.busy = true
+	m.busyLabel = label
+	m.cancel = cancel
+	m.err = nil
+	m.message = ""
+	m.hookLog = nil
+
+	return tea.Batch(m.spinner.Tick, func() tea.Msg {
+		return opDoneMsg{id: id, result: fn(ctx)}
+	})
+}
+
+// finishOp clears the in-flight operation state
+func (m *Model) finishOp() {
+	if m.cancel != nil {
+		m.cancel()
+	}
+	m.cancel = nil
+	m.busy = false
+	m.busyLabel = ""
+}
+
+// cancelOp aborts the in-flight operation, killing the git process. The
+// model stays on the current view.
+func (m *Model) cancelOp() {
+	m.finishOp()
+	m.opID++
+	m.message = "Operation cancelled"
+}
+
+// loadWorktrees lists worktrees for target. The list view also gathers
+// the status of every worktree for the dashboard, the remove view which
+// branches are already merged.
+func (m *Model) loadWorktrees(target viewState) tea.Cmd {
+	repo := m.repo
+	return m.startOp("Loading worktrees...", func(ctx context.Context) tea.Msg {
+		worktrees, err := repo.ListWorktrees(ctx)
+		if err != nil {
+			return worktreesLoadedMsg{target: target, err: err}
+		}
+
+		if target == removeView {
+			// Merged branches can be marked for removal in one go. Like
+			// rtr gc, they are merged into the default branch, whichever
+			// worktree rtr runs in.
+			defaultBranch, err := repo.DefaultBranch(ctx)
+			if err != nil {
+				// Without a default branch nothing counts as merged
+				return worktreesLoadedMsg{target: target, worktrees: worktrees, err: ctx.Err()}
+			}
+			merged, err := repo.MergedBranches(ctx, defaultBranch)
+			delete(merged, defaultBranch)
+			return worktreesLoadedMsg{target: target, worktrees: worktrees, merged: merged, err: err}
+		}
+		if target != listView {
+			return worktreesLoadedMsg{target: target, worktrees: worktrees}
+		}
+
+		statuses, statusErrs := repo.WorktreeStatuses(ctx, worktrees)
+		if ctx.Err() != nil {
+			return worktreesLoadedMsg{target: target, err: ctx.Err()}
+		}
+		return worktreesLoadedMsg{
+			target:     target,
+			worktrees:  worktrees,
+			statuses:   statuses,
+			statusErrs: statusErrs,
+		}
+	})
+}
+
+func (m *Model) loadBranches(target viewState) tea.Cmd {
+	repo := m.repo
+	return m.startOp("Loading branches...", func(ctx context.Context) tea.Msg {
+		branches, err := repo.ListBranches(ctx)
+		return branchesLoadedMsg{target: target, branches: branches, email: repo.UserEmail(ctx), err: err}
+	})
+}
+
+// listBranches lists branches for target, fetching first if the config
+// asks for it
+func (m *Model) listBranches(target viewState) tea.Cmd {
+	if m.repo.Config().ShouldFetchOnAdd() {
+		return m.fetchBranches(target)
+	}
+	return m.loadBranches(target)
+}
+
+// fetchBranches fetches every remote, pruning deleted branches, and then
+// lists branches for target. A remote that cannot be fetched does not
+// prevent listing the others.
+func (m *Model) fetchBranches(target viewState) tea.Cmd {
+	repo := m.repo
+	return m.startHookOp("Fetching remotes...", func(ctx context.Context, log io.Writer) tea.Msg {
+		remotes, err := repo.Remotes(ctx)
+		if err != nil {
+			return branchesLoadedMsg{target: target, err: err}
+		}
+
+		var failed []string
+		for i, remote := range remotes {
+			fmt.Fprintf(log, "[%d/%d] Fetching %s...\n", i+1, len(remotes), remote)
+			if err := repo.Fetch(ctx, remote); err != nil {
+				if ctx.Err() != nil {
+					return branchesLoadedMsg{target: target, err: err}
+				}
+				fmt.Fprintf(log, "%v\n", err)
+				// Keep the summary short; git's hints take several lines
+				first, _, _ := strings.Cut(err.Error(), "\n")
+				failed = append(failed, first)
+			}
+		}
+
+		var fetchErr error
+		if len(failed) > 0 {
+			fetchErr = errors.New(strings.Join(failed, "; "))
+		}
+		branches, err := repo.ListBranches(ctx)
+		return branchesLoadedMsg{target: target, branches: branches, email: repo.UserEmail(ctx), fetchErr: fetchErr, err: err}
+	})
+}
+
+func (m *Model) suggestBranchNames() tea.Cmd {
+	repo := m.repo
+	return m.startOp("Analyzing branch names...", func(ctx context.Context) tea.Msg {
+		suggestions, err := repo.SuggestBranchNames(ctx)
+		return branchNamesSuggestedMsg{suggestions: suggestions, err: err}
+	})
+}
+
+// loadProviderBranchNames asks p for branch names starting with prefix,
+// e.g. one for each issue assigned to the user
+func (m *Model) loadProviderBranchNames(p git.BranchNameProvider, prefix string) tea.Cmd {
+	return m.startOp(fmt.Sprintf("Loading %s...", p.Name()), func(ctx context.Context) tea.Msg {
+		suggestions, err := p.SuggestBranchNames(ctx, prefix)
+		return providerBranchNamesMsg{source: p.Name(), suggestions: suggestions, err: err}
+	})
+}
+
+func (m *Model) suggestPaths(branch string) tea.Cmd {
+	repo := m.repo
+	return m.startOp("Analyzing worktree paths...", func(ctx context.Context) tea.Msg {
+		suggestions, err := repo.SuggestPaths(ctx, branch)
+		if err != nil {
+			return pathsSuggestedMsg{branch: branch, err: err}
+		}
+		// Preview the local files the new worktree will get
+		plan, err := files.PlanForRepo(ctx, repo)
+		return pathsSuggestedMsg{branch: branch, suggestions: suggestions, files: plan, err: err}
+	})
+}
+
+// addWorktree creates a worktree at path for the selected branch, creating
+// the branch from baseBranch first in new branch mode. It then brings in
+// the local files of the main worktree and runs the post-create hooks.
+// template is the path template path was made from, remembered with the
+// other choices to rank later suggestions.
+func (m *Model) addWorktree(path, template string) tea.Cmd {
+	repo := m.repo
+	branch, base, isNew, trackRef, detach := m.selectedBranch, m.baseBranch, m.isNewBranch, m.trackRef, m.detach
+	plan := m.filePlan
+	commands, skipped := repo.Config().Hooks.PostCreate, repo.Config().SkippedHooks.PostCreate
+	return m.startHookOp("Creating worktree at "+path+"...", func(ctx context.Context, log io.Writer) tea.Msg {
+		// base is reused for the hooks below
+		newBase := base
+
+		var err error
+		switch {
+		case isNew:
+			err = repo.AddWorktreeWithNewBranch(ctx, path, branch, base)
+		case detach:
+			err = repo.AddWorktreeDetached(ctx, path, branch)
+			base, branch = branch, ""
+		case trackRef != "":
+			err = repo.AddWorktreeTracking(ctx, path, branch, trackRef)
+			base = trackRef
+		default:
+			err = repo.AddWorktree(ctx, path, branch)
+			base = ""
+		}
+		if err == nil {
+			// The history only ranks suggestions, so failing to save it
+			// does not fail the worktree
+			_ = repo.RememberWorktree(template, branch, isNew, newBase)
+		}
+
+		showLog := len(plan.Entries) > 0 || len(commands) > 0 || len(skipped) > 0
+		if err != nil || !showLog {
+			return worktreeAddedMsg{path: path, err: err}
+		}
+
+		absPath, err := repo.AbsPath(path)
+		if err == nil {
+			err = plan.Apply(absPath, log)
+		}
+		if err == nil {
+			hooks.WarnSkipped(log, skipped)
+			err = hooks.RunForWorktree(ctx, repo, commands, path, branch, base, log)
+		}
+		return worktreeAddedMsg{path: path, showLog: true, setupErr: err}
+	})
+}
+
+func (m *Model) preflightRemoval(wt git.Worktree) tea.Cmd {
+	repo := m.repo
+	return m.startOp("Checking "+wt.Path+" for unsaved work...", func(ctx context.Context) tea.Msg {
+		// The branch can be deleted along with the worktree
+		preflight, err := repo.PreflightRemoval(ctx, wt, true)
+		return removalPreflightMsg{preflight: preflight, err: err}
+	})
+}
+
+// removeWorktree runs the pre-remove hooks in the worktree and removes it.
+// A failing hook keeps the worktree.
+func (m *Model) removeWorktree(wt git.Worktree, opts git.RemoveOptions) tea.Cmd {
+	repo := m.repo
+	commands, skipped := repo.Config().Hooks.PreRemove, repo.Config().SkippedHooks.PreRemove
+	if wt.Prunable {
+		// The directory is gone; there is nothing to run the hooks in
+		commands, skipped = nil, nil
+	}
+	return m.startHookOp("Removing worktree at "+wt.Path+"...", func(ctx context.Context, log io.Writer) tea.Msg {
+		hooksRan := len(commands) > 0 || len(skipped) > 0
+		hooks.WarnSkipped(log, skipped)
+		if err := hooks.RunForWorktree(ctx, repo, commands, wt.Path, wt.Branch, "", log); err != nil {
+			return worktreeRemovedMsg{path: wt.Path, opts: opts, hooksRan: hooksRan, err: fmt.Errorf("%w; the worktree was kept", err)}
+		}
+		err := repo.RemoveWorktree(ctx, wt.Path, opts)
+		return worktreeRemovedMsg{path: wt.Path, opts: opts, hooksRan: hooksRan, err: err}
+	})
+}
+
+func (m *Model) preflightBulkRemoval(worktrees []git.Worktree) tea.Cmd {
+	repo := m.repo
+	label := fmt.Sprintf("Checking %d worktrees for unsaved work...", len(worktrees))
+	return m.startOp(label, func(ctx context.Context) tea.Msg {
+		preflights := make([]git.RemovalPreflight, 0, len(worktrees))
+		for _, wt := range worktrees {
+			preflight, err := repo.PreflightRemoval(ctx, wt, false)
+			if err != nil {
+				return bulkPreflightMsg{err: err}
+			}
+			preflights = append(preflights, preflight)
+		}
+		return bulkPreflightMsg{preflights: preflights}
+	})
+}
+
+// removeWorktrees removes the worktrees one by one. Without force, those
+// git would refuse to remove are skipped. Cancelling stops the batch after
+// the worktree being removed. With prune, stale administrative files are
+// pruned afterwards.
+func (m *Model) removeWorktrees(preflights []git.RemovalPreflight, force, prune bool) tea.Cmd {
+	repo := m.repo
+	commands := repo.Config().Hooks.PreRemove
+	label := fmt.Sprintf("Removing %d worktrees...", len(preflights))
+	return m.startHookOp(label, func(ctx context.Context, log io.Writer) tea.Msg {
+		hooks.WarnSkipped(log, repo.Config().SkippedHooks.PreRemove)
+		results := make([]bulkRemoveResult, 0, len(preflights))
+		for _, p := range preflights {
+			wt := p.Worktree
+			result := bulkRemoveResult{path: wt.Path}
+			switch {
+			case ctx.Err() != nil:
+				result.skipped = "cancelled"
+			case p.NeedsForce() && !force:
+				result.skipped = "has unsaved work or is locked"
+			default:
+				if !wt.Prunable {
+					if err := hooks.RunForWorktree(ctx, repo, commands, wt.Path, wt.Branch, "", log); err != nil {
+						result.err = fmt.Errorf("%w; the worktree was kept", err)
+						break
+					}
+				}
+				result.err = repo.RemoveWorktree(ctx, wt.Path, git.RemoveOptions{Force: force})
+			}
+			results = append(results, result)
+		}
+
+		var err error
+		if prune && ctx.Err() == nil {
+			err = repo.PruneWorktrees(ctx)
+		}
+		return bulkRemovedMsg{results: results, err: err}
+	})
+}
+
+func (m *Model) findStaleWorktrees() tea.Cmd {
+	repo := m.repo
+	return m.startOp("Looking for stale worktrees...", func(ctx context.Context) tea.Msg {
+		stale, mergedInto, err := repo.FindStaleWorktrees(ctx, git.StaleOptions{MaxAge: repo.Config().GCMaxAge()})
+		return staleWorktreesMsg{stale: stale, mergedInto: mergedInto, err: err}
+	})
+}